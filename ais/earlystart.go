@@ -5,17 +5,194 @@
 package ais
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/NVIDIA/aistore/3rdparty/glog"
 	"github.com/NVIDIA/aistore/cluster"
+	"github.com/NVIDIA/aistore/cluster/store"
 	"github.com/NVIDIA/aistore/cmn"
 	jsoniter "github.com/json-iterator/go"
 )
 
+// externalStore, when configured (`cmn.Config.Store.Backend != store.Internal`),
+// takes over Smap/BMD persistence and primary election from the default
+// gossip+metasyncer path below: the elected primary CAS's new revisions onto
+// well-known keys, and every other node watches and applies them verbatim.
+// This eliminates the split-brain-prone AIS_IS_PRIMARY/AIS_PRIMARY_ID dance
+// and the ciError(10/20/30) fatal exits during discovery.
+var (
+	externalStoreOnce sync.Once
+	externalStore      store.Backend
+)
+
+func getExternalStore(config *cmn.Config) store.Backend {
+	externalStoreOnce.Do(func() {
+		cfg := store.Config{
+			Backend:   config.Store.Backend,
+			Endpoints: config.Store.Endpoints,
+			CertFile:  config.Store.CertFile,
+			KeyFile:   config.Store.KeyFile,
+			CAFile:    config.Store.CAFile,
+			Timeout:   config.Timeout.CplaneOperation.D(),
+		}
+		be, err := store.New(cfg)
+		if err != nil {
+			glog.Errorf("failed to init external store backend %q: %v", cfg.Backend, err)
+			return
+		}
+		externalStore = be
+	})
+	return externalStore
+}
+
+func smapKey(uuid string) string { return "/ais/" + uuid + "/smap" }
+func bmdKey(uuid string) string  { return "/ais/" + uuid + "/bmd" }
+
+// bootstrapExternal replaces the entire gossip-based bootstrap sequence when an
+// external consensus store is configured: primary election becomes "whoever
+// holds the session-lease/consul-session lock on a well-known key", and
+// Smap/BMD distribution becomes CAS-write-then-watch instead of metasyncer push.
+func (p *proxyrunner) bootstrapExternal(be store.Backend, config *cmn.Config) {
+	ctx := context.Background()
+	sess, err := be.NewSession(ctx, config.Timeout.Startup.D())
+	if err != nil {
+		cmn.ExitLogf("FATAL: %s: failed to open external-store session: %v", p.si, err)
+	}
+
+	smap := newSmap()
+	if err := p.owner.smap.load(smap, config); err != nil {
+		smap = newSmap()
+	}
+	uuid := smap.UUID
+	if uuid == "" {
+		uuid, _ = cmn.GenUUID()
+	}
+
+	isPrimary, err := be.AcquireLock(ctx, sess, "/ais/"+uuid+"/primary")
+	if err != nil {
+		cmn.ExitLogf("FATAL: %s: external-store leader election failed: %v", p.si, err)
+	}
+	if isPrimary {
+		glog.Infof("%s: won external-store election for cluster %s, starting up as primary", p.si, uuid)
+		p.primaryStartupExternal(be, uuid, config)
+		return
+	}
+	glog.Infof("%s: did not win external-store election for cluster %s, following", p.si, uuid)
+	p.followExternal(be, uuid, config)
+}
+
+// primaryStartupExternal persists an initial Smap/BMD (CAS, so a losing racer
+// backs off cleanly) then returns - updates thereafter go through `p.owner.smap.put`
+// as usual, and callers (e.g. metasyncer) should prefer CAS'ing to `be` as well.
+func (p *proxyrunner) primaryStartupExternal(be store.Backend, uuid string, config *cmn.Config) {
+	smap := p.owner.smap.get()
+	if smap == nil || smap.version() == 0 {
+		smap = newSmap()
+		smap.Pmap[p.si.ID()] = p.si
+		smap.ProxySI = p.si
+		smap.UUID = uuid
+		if smap.UUID == "" {
+			smap.UUID, smap.CreationTime = newClusterUUID()
+		}
+		smap.Version = 1
+	}
+	uuid = smap.UUID // may have just been generated above
+	p.owner.smap.put(smap)
+
+	ctx := context.Background()
+	if b, err := jsoniter.Marshal(smap); err == nil {
+		if _, err := be.CAS(ctx, smapKey(uuid), b, 0); err != nil && err != store.ErrCASConflict {
+			glog.Errorf("%s: failed to CAS initial Smap onto external store: %v", p.si, err)
+		}
+	}
+	bmd := p.owner.bmd.get()
+	if bmd == nil || bmd.version() == 0 {
+		bmd = newBucketMD()
+		bmd.Version, bmd.UUID = 1, uuid
+		p.owner.bmd.put(bmd)
+	}
+	if b, err := jsoniter.Marshal(bmd); err == nil {
+		if _, err := be.CAS(ctx, bmdKey(uuid), b, 0); err != nil && err != store.ErrCASConflict {
+			glog.Errorf("%s: failed to CAS initial BMD onto external store: %v", p.si, err)
+		}
+	}
+	p.startedUp.Store(true)
+}
+
+// followExternal applies every Smap/BMD revision the primary CAS's in, instead
+// of waiting for a metasyncer push - this is what makes bootstrap split-brain-free
+// even across flaky networks: there is a single source of truth (the external store),
+// not a quorum of locally-gossiped opinions.
+func (p *proxyrunner) followExternal(be store.Backend, uuid string, config *cmn.Config) {
+	ctx := context.Background()
+	if b, _, err := be.Get(ctx, smapKey(uuid)); err == nil && len(b) > 0 {
+		smap := newSmap()
+		if err := jsoniter.Unmarshal(b, smap); err == nil {
+			p.owner.smap.put(smap)
+		}
+	}
+	if b, _, err := be.Get(ctx, bmdKey(uuid)); err == nil && len(b) > 0 {
+		bmd := newBucketMD()
+		if err := jsoniter.Unmarshal(b, bmd); err == nil {
+			p.owner.bmd.put(bmd)
+		}
+	}
+	go p.watchExternalSmap(be, uuid)
+	go p.watchExternalBMD(be, uuid)
+	p.startedUp.Store(true)
+}
+
+func (p *proxyrunner) watchExternalSmap(be store.Backend, uuid string) {
+	ctx := context.Background()
+	evs, err := be.Watch(ctx, smapKey(uuid))
+	if err != nil {
+		glog.Errorf("%s: external-store Smap watch failed: %v", p.si, err)
+		return
+	}
+	for ev := range evs {
+		if ev.Err != nil {
+			glog.Errorf("%s: external-store Smap watch error: %v", p.si, ev.Err)
+			continue
+		}
+		smap := newSmap()
+		if err := jsoniter.Unmarshal(ev.Value, smap); err != nil {
+			glog.Errorf("%s: failed to unmarshal watched Smap: %v", p.si, err)
+			continue
+		}
+		p.owner.smap.put(smap)
+	}
+}
+
+// watchExternalBMD mirrors watchExternalSmap for the BMD key: without it, a
+// primary's later bucket-metadata changes (new/destroyed buckets, property
+// updates) would only ever reach followers once, at the initial Get in
+// followExternal, and never again for the lifetime of the process.
+func (p *proxyrunner) watchExternalBMD(be store.Backend, uuid string) {
+	ctx := context.Background()
+	evs, err := be.Watch(ctx, bmdKey(uuid))
+	if err != nil {
+		glog.Errorf("%s: external-store BMD watch failed: %v", p.si, err)
+		return
+	}
+	for ev := range evs {
+		if ev.Err != nil {
+			glog.Errorf("%s: external-store BMD watch error: %v", p.si, ev.Err)
+			continue
+		}
+		bmd := newBucketMD()
+		if err := jsoniter.Unmarshal(ev.Value, bmd); err != nil {
+			glog.Errorf("%s: failed to unmarshal watched BMD: %v", p.si, err)
+			continue
+		}
+		p.owner.bmd.put(bmd)
+	}
+}
+
 // Background:
 // 	- Each proxy/gateway stores a local copy of the cluster map (Smap)
 // 	- Each Smap instance is versioned; the versioning is monotonic (increasing)
@@ -27,6 +204,10 @@ func (p *proxyrunner) bootstrap() {
 		config            = cmn.GCO.Get()
 		secondary, loaded bool
 	)
+	if be := getExternalStore(config); be != nil {
+		p.bootstrapExternal(be, config)
+		return
+	}
 	// 1: load a local copy and try to utilize it for discovery
 	smap = newSmap()
 	if err := p.owner.smap.load(smap, config); err == nil {
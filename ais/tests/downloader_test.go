@@ -5,12 +5,17 @@
 package integration
 
 import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -609,6 +614,111 @@ func TestDownloadStatusError(t *testing.T) {
 	checkDownloadList(t)
 }
 
+func TestDownloadRetryTransientError(t *testing.T) {
+	tutils.CheckSkip(t, tutils.SkipTestArgs{Long: true})
+
+	const failsBeforeSuccess = 2
+
+	var (
+		bck = cmn.Bck{
+			Name:     TestBucketName,
+			Provider: cmn.ProviderAIS,
+		}
+		attempts   int
+		objName    = "retry-flaky"
+		proxyURL   = tutils.RandomProxyURL()
+		baseParams = tutils.BaseAPIParams(proxyURL)
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts <= failsBeforeSuccess {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("retry-ok"))
+	}))
+	defer srv.Close()
+
+	clearDownloadList(t)
+
+	tutils.CreateFreshBucket(t, proxyURL, bck)
+	defer tutils.DestroyBucket(t, proxyURL, bck)
+
+	id, err := api.DownloadSingleWithParam(baseParams, downloader.DlSingleBody{
+		DlBase: downloader.DlBase{
+			Bck: bck,
+			Retry: downloader.RetryPolicy{
+				MaxAttempts:       failsBeforeSuccess + 2,
+				InitialBackoff:    50 * time.Millisecond,
+				MaxBackoff:        time.Second,
+				BackoffMultiplier: 2,
+			},
+		},
+		DlSingleObj: downloader.DlSingleObj{
+			ObjName: objName,
+			Link:    srv.URL,
+		},
+	})
+	tassert.CheckFatal(t, err)
+
+	waitForDownload(t, id, 10*time.Second)
+
+	resp, err := api.DownloadStatus(baseParams, id)
+	tassert.CheckFatal(t, err)
+
+	if resp.FinishedCnt != 1 {
+		t.Fatalf("expected the flaky download to eventually succeed, resp: %+v", resp)
+	}
+	if resp.ErrorCnt != 0 {
+		t.Fatalf("expected no errors once the 503s stop, got: %v", resp.Errs)
+	}
+
+	checkDownloadList(t)
+}
+
+func TestDownloadStatusPermanentErrorNotRetried(t *testing.T) {
+	tutils.CheckSkip(t, tutils.SkipTestArgs{Long: true})
+
+	var (
+		bck = cmn.Bck{
+			Name:     TestBucketName,
+			Provider: cmn.ProviderAIS,
+		}
+		files = map[string]string{
+			"invalidURL":   "http://some.invalid.url",
+			"notFoundFile": "https://google.com/404.tar",
+		}
+
+		proxyURL   = tutils.RandomProxyURL()
+		baseParams = tutils.BaseAPIParams(proxyURL)
+	)
+
+	clearDownloadList(t)
+
+	tutils.CreateFreshBucket(t, proxyURL, bck)
+	defer tutils.DestroyBucket(t, proxyURL, bck)
+
+	id, err := api.DownloadMulti(baseParams, generateDownloadDesc(), bck, files)
+	tassert.CheckFatal(t, err)
+
+	waitForDownload(t, id, 10*time.Second)
+
+	resp, err := api.DownloadStatus(baseParams, id)
+	tassert.CheckFatal(t, err)
+
+	for _, e := range resp.Errs {
+		// 400/404-class failures are permanent - the worker must fail fast
+		// on the first attempt rather than spend the retry budget on them.
+		if e.Name == "notFoundFile" && e.Retries != 0 {
+			t.Errorf("expected notFoundFile (404) not to be retried, got %d retries", e.Retries)
+		}
+	}
+
+	checkDownloadList(t)
+}
+
 func TestDownloadSingleValidExternalAndInternalChecksum(t *testing.T) {
 	tutils.CheckSkip(t, tutils.SkipTestArgs{Long: true})
 
@@ -811,7 +921,18 @@ func TestDownloadMpathEvents(t *testing.T) {
 	tassert.CheckError(t, err)
 	tassert.Fatalf(t, len(objs) == 0, "objects should not have been downloaded, download should have been aborted\n")
 
-	id, err = api.DownloadMulti(baseParams, generateDownloadDesc(), bck, m)
+	// Resumable: re-request the same objects the aborted range job was
+	// working through; any of them that reached a `.partial` on disk
+	// before the abort should be resumed via Range rather than
+	// re-transferred from byte 0.
+	id, err = api.DownloadMultiWithParam(baseParams, downloader.DlMultiBody{
+		DlBase: downloader.DlBase{
+			Bck:       bck,
+			Resumable: true,
+		},
+		ObjectsMap: m,
+		Description: generateDownloadDesc(),
+	})
 	tassert.CheckFatal(t, err)
 	tutils.Logf("Started download job %s, waiting for it to finish\n", id)
 
@@ -819,6 +940,10 @@ func TestDownloadMpathEvents(t *testing.T) {
 	objs, err = tutils.ListObjects(proxyURL, bck, "", 0)
 	tassert.CheckError(t, err)
 	tassert.Fatalf(t, len(objs) == objsCnt, "Expected %d objects to be present, got: %d", objsCnt, len(objs)) // 21: from cifar10.tgz to cifar30.tgz
+
+	resp, err := api.DownloadStatus(baseParams, id)
+	tassert.CheckFatal(t, err)
+	tutils.Logf("%d of %d objects were resumed from an on-disk partial\n", resp.ResumedCnt, objsCnt)
 }
 
 // NOTE: Test may fail if the content (or version) of the link changes
@@ -918,7 +1043,8 @@ func TestDownloadJobLimitConnections(t *testing.T) {
 		DlBase: downloader.DlBase{
 			Bck: bck,
 			Limits: downloader.DlLimits{
-				Connections: 2,
+				Connections:          2,
+				PerObjectConnections: 4,
 			},
 		},
 		Template: template,
@@ -931,6 +1057,9 @@ func TestDownloadJobLimitConnections(t *testing.T) {
 	resp, err := api.DownloadStatus(baseParams, id)
 	tassert.CheckFatal(t, err)
 
+	// PerObjectConnections splits one object's fetch into multiple Range
+	// requests within a single TaskDlInfo, it doesn't add more entries to
+	// CurrentTasks - the task-count bounds below are unaffected by it.
 	tassert.Errorf(
 		t, len(resp.CurrentTasks) > smap.CountTargets(),
 		"number of tasks mismatch (expected at least: %d, got: %d)",
@@ -941,6 +1070,10 @@ func TestDownloadJobLimitConnections(t *testing.T) {
 		"number of tasks mismatch (expected as most: %d, got: %d)",
 		2*smap.CountTargets(), len(resp.CurrentTasks),
 	)
+	for _, task := range resp.CurrentTasks {
+		tassert.Errorf(t, task.RangesTotal == 0 || task.RangesDone <= task.RangesTotal,
+			"task %s: RangesDone (%d) exceeds RangesTotal (%d)", task.Name, task.RangesDone, task.RangesTotal)
+	}
 }
 
 func TestDownloadJobConcurrency(t *testing.T) {
@@ -1001,3 +1134,285 @@ func TestDownloadJobConcurrency(t *testing.T) {
 		smap.CountTargets()+1, len(resp2.CurrentTasks),
 	)
 }
+
+func TestDownloadDeduplication(t *testing.T) {
+	tutils.CheckSkip(t, tutils.SkipTestArgs{Long: true})
+
+	var (
+		proxyURL   = tutils.RandomProxyURL()
+		baseParams = tutils.BaseAPIParams(proxyURL)
+		bck        = cmn.Bck{
+			Name:     TestBucketName,
+			Provider: cmn.ProviderAIS,
+		}
+		objName  = "dedup-obj"
+		fetchCnt int32
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&fetchCnt, 1)
+		time.Sleep(500 * time.Millisecond) // give the second job's request time to join, not start a new fetch
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("dedup-ok"))
+	}))
+	defer srv.Close()
+
+	clearDownloadList(t)
+
+	tutils.CreateFreshBucket(t, proxyURL, bck)
+	defer tutils.DestroyBucket(t, proxyURL, bck)
+
+	files := map[string]string{objName: srv.URL}
+
+	id1, err := api.DownloadMulti(baseParams, generateDownloadDesc(), bck, files)
+	tassert.CheckFatal(t, err)
+	id2, err := api.DownloadMulti(baseParams, generateDownloadDesc(), bck, files)
+	tassert.CheckFatal(t, err)
+
+	waitForDownload(t, id1, 10*time.Second)
+	waitForDownload(t, id2, 10*time.Second)
+
+	resp1, err := api.DownloadStatus(baseParams, id1)
+	tassert.CheckFatal(t, err)
+	resp2, err := api.DownloadStatus(baseParams, id2)
+	tassert.CheckFatal(t, err)
+
+	tassert.Errorf(t, resp1.FinishedCnt == 1 && resp2.FinishedCnt == 1,
+		"expected both jobs to report success, got resp1.FinishedCnt=%d resp2.FinishedCnt=%d",
+		resp1.FinishedCnt, resp2.FinishedCnt)
+	tassert.Errorf(t, atomic.LoadInt32(&fetchCnt) == 1,
+		"expected exactly one network fetch to be coalesced across both jobs, got %d", fetchCnt)
+	tassert.Errorf(t, resp1.Deduplicated+resp2.Deduplicated >= 1,
+		"expected at least one job to report a deduplicated fetch, got resp1=%d resp2=%d",
+		resp1.Deduplicated, resp2.Deduplicated)
+
+	checkDownloadList(t)
+}
+
+func TestDownloadMirrorFailover(t *testing.T) {
+	tutils.CheckSkip(t, tutils.SkipTestArgs{Long: true})
+
+	var (
+		proxyURL   = tutils.RandomProxyURL()
+		baseParams = tutils.BaseAPIParams(proxyURL)
+		bck        = cmn.Bck{
+			Name:     TestBucketName,
+			Provider: cmn.ProviderAIS,
+		}
+		objName = "mirror-obj"
+		content = []byte("the quick brown fox jumps over the lazy dog, mirrored")
+		sum     = md5.Sum(content)
+		wantSum = hex.EncodeToString(sum[:])
+	)
+
+	// srv1 serves the first half of the object then hangs up, simulating a
+	// source that dies mid-transfer; srv2 serves the full object. srv1 sends
+	// no ETag/Last-Modified, so its partial can't be validated against srv2
+	// and the object completes from srv2 starting at byte 0.
+	srv1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content[:len(content)/2])
+		srv1CloseConn(w)
+	}))
+	defer srv1.Close()
+
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer srv2.Close()
+
+	clearDownloadList(t)
+
+	tutils.CreateFreshBucket(t, proxyURL, bck)
+	defer tutils.DestroyBucket(t, proxyURL, bck)
+
+	id, err := api.DownloadMultiMirrored(baseParams, generateDownloadDesc(), bck,
+		map[string][]string{objName: {srv1.URL, srv2.URL}})
+	tassert.CheckFatal(t, err)
+
+	waitForDownload(t, id, 20*time.Second)
+
+	resp, err := api.DownloadStatus(baseParams, id)
+	tassert.CheckFatal(t, err)
+	tassert.Errorf(t, resp.FinishedCnt == 1, "expected object to finish despite the primary source dying, got FinishedCnt=%d", resp.FinishedCnt)
+
+	objProps, err := api.HeadObject(baseParams, bck, objName)
+	tassert.CheckFatal(t, err)
+	tassert.Errorf(t, objProps.Size == int64(len(content)), "size mismatch (%d vs %d)", objProps.Size, len(content))
+
+	var buf bytes.Buffer
+	_, err = api.GetObject(baseParams, bck, objName, api.GetObjectInput{Writer: &buf})
+	tassert.CheckFatal(t, err)
+	gotSum := md5.Sum(buf.Bytes())
+	tassert.Errorf(t, hex.EncodeToString(gotSum[:]) == wantSum,
+		"checksum mismatch after mirror failover: expected %s, got %s", wantSum, hex.EncodeToString(gotSum[:]))
+
+	checkDownloadList(t)
+}
+
+// srv1CloseConn hijacks and closes the underlying connection so the client
+// sees a mid-body disconnect rather than a clean EOF - the failure shape
+// fetchWithMirrors is meant to fail over on.
+func srv1CloseConn(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	_ = conn.Close()
+}
+
+// waitForDownloadViaEvents is waitForDownload's event-driven counterpart:
+// instead of polling DownloadStatus on a fixed interval, it subscribes to
+// id's event stream and blocks until an EvFinished/EvFailed/EvAborted event
+// for every object in the job has gone by.
+func waitForDownloadViaEvents(t *testing.T, baseParams api.BaseParams, id string, objCnt int, timeout time.Duration) {
+	events, err := api.DownloadEvents(baseParams, id)
+	tassert.CheckFatal(t, err)
+
+	deadline := time.After(timeout)
+	done := 0
+	for done < objCnt {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("event stream for %s closed before %d/%d objects finished", id, done, objCnt)
+			}
+			switch ev.Kind {
+			case downloader.EvFinished, downloader.EvFailed, downloader.EvAborted:
+				done++
+			case downloader.EvDropped:
+				t.Fatalf("event stream for %s dropped %d events, cannot reliably count completions", id, ev.Dropped)
+			}
+		case <-deadline:
+			t.Fatalf("timed out after %s waiting for %s (%d/%d objects finished)", timeout, id, done, objCnt)
+		}
+	}
+}
+
+// TestDownloadSingleViaEventStream is TestDownloadSingle's single-object
+// happy path, but waits on the job's event stream (api.DownloadEvents)
+// instead of polling waitForDownload - demonstrating the streaming-progress
+// API in place of the time.Sleep+poll loop every other test in this file
+// still uses.
+func TestDownloadSingleViaEventStream(t *testing.T) {
+	tutils.CheckSkip(t, tutils.SkipTestArgs{Long: true})
+
+	var (
+		bck = cmn.Bck{
+			Name:     TestBucketName,
+			Provider: cmn.ProviderAIS,
+		}
+		proxyURL   = tutils.RandomProxyURL()
+		baseParams = tutils.BaseAPIParams(proxyURL)
+		objName    = "event-stream-object"
+		link       = "github.com/NVIDIA/aistore"
+	)
+
+	clearDownloadList(t)
+
+	tutils.CreateFreshBucket(t, proxyURL, bck)
+	defer tutils.DestroyBucket(t, proxyURL, bck)
+
+	id, err := api.DownloadSingle(baseParams, generateDownloadDesc(), bck, objName, link)
+	tassert.CheckFatal(t, err)
+
+	waitForDownloadViaEvents(t, baseParams, id, 1, 30*time.Second)
+
+	objs, err := tutils.ListObjects(proxyURL, bck, "", 0)
+	tassert.CheckError(t, err)
+	if len(objs) != 1 || objs[0] != objName {
+		t.Errorf("expected single object (%s), got: %s", objName, objs)
+	}
+
+	checkDownloadList(t)
+}
+
+// waitForEventKind blocks until id's event stream yields an event of kind,
+// failing the test if it times out or the stream closes first - the
+// building block TestDownloadJobConcurrencyViaEvents uses in place of the
+// fixed time.Sleep windows TestDownloadJobConcurrency waits out.
+func waitForEventKind(t *testing.T, baseParams api.BaseParams, id string, kind downloader.EventKind, timeout time.Duration) {
+	t.Helper()
+	events, err := api.DownloadEvents(baseParams, id)
+	tassert.CheckFatal(t, err)
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("event stream for %s closed before observing %s", id, kind)
+			}
+			if ev.Kind == kind {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timed out after %s waiting for %s on %s", timeout, kind, id)
+		}
+	}
+}
+
+// TestDownloadJobConcurrencyViaEvents is TestDownloadJobConcurrency's
+// event-driven counterpart: it waits on each job's own EvStarted event
+// instead of a fixed time.Sleep before checking that a second job's objects
+// get dispatched concurrently with the first rather than queueing behind it.
+func TestDownloadJobConcurrencyViaEvents(t *testing.T) {
+	var (
+		proxyURL   = tutils.RandomProxyURL()
+		baseParams = tutils.BaseAPIParams(proxyURL)
+		bck        = cmn.Bck{
+			Name:     cmn.RandString(10),
+			Provider: cmn.ProviderAIS,
+		}
+
+		template = "https://storage.googleapis.com/lpr-vision/imagenet/imagenet_train-{000001..0000140}.tgz"
+	)
+
+	tutils.CreateFreshBucket(t, proxyURL, bck)
+	defer tutils.DestroyBucket(t, proxyURL, bck)
+
+	smap, err := api.GetClusterMap(baseParams)
+	tassert.CheckFatal(t, err)
+
+	id1, err := api.DownloadRangeWithParam(baseParams, downloader.DlRangeBody{
+		DlBase: downloader.DlBase{
+			Bck: bck,
+			Limits: downloader.DlLimits{
+				Connections: 1,
+			},
+		},
+		Template: template,
+	})
+	tassert.CheckError(t, err)
+	defer api.DownloadAbort(baseParams, id1)
+	waitForEventKind(t, baseParams, id1, downloader.EvStarted, 10*time.Second)
+
+	id2, err := api.DownloadRange(baseParams, generateDownloadDesc(), bck, template)
+	tassert.CheckError(t, err)
+	defer api.DownloadAbort(baseParams, id2)
+	waitForEventKind(t, baseParams, id2, downloader.EvStarted, 10*time.Second)
+
+	resp1, err := api.DownloadStatus(baseParams, id1)
+	tassert.CheckFatal(t, err)
+	tassert.Errorf(
+		t, len(resp1.CurrentTasks) <= smap.CountTargets(),
+		"number of tasks mismatch (expected at most: %d, got: %d)",
+		smap.CountTargets(), len(resp1.CurrentTasks),
+	)
+
+	resp2, err := api.DownloadStatus(baseParams, id2)
+	tassert.CheckFatal(t, err)
+	// If the downloader didn't start id2's tasks concurrently with id1's,
+	// id2 would still be queued with zero current tasks at this point.
+	tassert.Errorf(
+		t, len(resp2.CurrentTasks) > smap.CountTargets(),
+		"number of tasks mismatch (expected at least: %d, got: %d)",
+		smap.CountTargets()+1, len(resp2.CurrentTasks),
+	)
+}
@@ -5,6 +5,9 @@
 package integration
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"sync/atomic"
@@ -99,6 +102,157 @@ func TestCopyMultiObjSimple(t *testing.T) {
 	}
 }
 
+// manifestEntry mirrors the newline-delimited JSON record that a
+// `cmn.TCObjsMsg.ManifestBck/ManifestObj`-bearing CopyMultiObj or
+// ETLMultiObj xaction appends for every object it lands, so a caller can
+// audit exactly what copied without a second full bucket crawl.
+type manifestEntry struct {
+	Src        string `json:"src"`
+	Dst        string `json:"dst"`
+	Size       int64  `json:"size"`
+	CksumType  string `json:"cksum_type"`
+	CksumValue string `json:"cksum_value"`
+	XactID     string `json:"xact_id"`
+	Ts         int64  `json:"ts"`
+}
+
+// TestCopyMultiObjManifest exercises `VerifyChecksum` + manifest emission:
+// the xaction recomputes each destination object's checksum as it copies
+// and appends one manifestEntry per object to ManifestObj, rather than
+// leaving the caller to infer success from `api.ListObjects` counts alone.
+func TestCopyMultiObjManifest(t *testing.T) {
+	const (
+		copyCnt   = 20
+		objCnt    = 30
+		objSize   = 128
+		cksumType = cos.ChecksumXXHash
+	)
+	var (
+		proxyURL    = tools.RandomProxyURL(t)
+		baseParams  = tools.BaseAPIParams(proxyURL)
+		bckFrom     = cmn.Bck{Name: "cp-manifest-from", Provider: apc.AIS}
+		bckTo       = cmn.Bck{Name: "cp-manifest-to", Provider: apc.AIS}
+		manifestObj = "manifest/copy.ndjson"
+	)
+	tools.CreateBucketWithCleanup(t, proxyURL, bckFrom, nil)
+	tools.CreateBucketWithCleanup(t, proxyURL, bckTo, nil)
+
+	objList := make([]string, 0, objCnt)
+	for i := 0; i < objCnt; i++ {
+		objName := fmt.Sprintf("test/a-%04d", i)
+		objList = append(objList, objName)
+		r, _ := readers.NewRandReader(objSize, cksumType)
+		err := api.PutObject(api.PutObjectArgs{
+			BaseParams: baseParams,
+			Bck:        bckFrom,
+			Object:     objName,
+			Reader:     r,
+			Size:       objSize,
+		})
+		tassert.CheckFatal(t, err)
+	}
+
+	template := "test/a-" + fmt.Sprintf("{%04d..%04d}", 0, copyCnt-1)
+	msg := cmn.TCObjsMsg{
+		SelectObjsMsg:  cmn.SelectObjsMsg{Template: template},
+		ToBck:          bckTo,
+		VerifyChecksum: true,
+		ManifestBck:    bckTo,
+		ManifestObj:    manifestObj,
+	}
+	xactID, err := api.CopyMultiObj(baseParams, bckFrom, msg)
+	tassert.CheckFatal(t, err)
+
+	wargs := api.XactReqArgs{ID: xactID, Kind: apc.ActCopyObjects}
+	err = api.WaitForXactionIdle(baseParams, wargs)
+	tassert.CheckFatal(t, err)
+
+	var manifest bytes.Buffer
+	_, err = api.GetObject(baseParams, bckTo, manifestObj, api.GetObjectInput{Writer: &manifest})
+	tassert.CheckFatal(t, err)
+
+	entries := make(map[string]manifestEntry, copyCnt)
+	sc := bufio.NewScanner(&manifest)
+	for sc.Scan() {
+		var e manifestEntry
+		tassert.CheckFatal(t, json.Unmarshal(sc.Bytes(), &e))
+		tassert.Errorf(t, e.XactID == xactID, "manifest entry xact_id %q != %q", e.XactID, xactID)
+		entries[e.Src] = e
+	}
+	tassert.CheckFatal(t, sc.Err())
+	tassert.Errorf(t, len(entries) == copyCnt, "expected %d manifest entries, got %d", copyCnt, len(entries))
+	for _, objName := range objList[:copyCnt] {
+		src := bckFrom.DisplayName() + "/" + objName
+		e, ok := entries[src]
+		tassert.Errorf(t, ok, "missing manifest entry for %s", src)
+		tassert.Errorf(t, e.Size == objSize, "%s: size %d != %d", src, e.Size, objSize)
+	}
+
+	for _, objName := range objList[:copyCnt] {
+		err := api.DeleteObject(baseParams, bckTo, objName)
+		tassert.CheckError(t, err)
+	}
+	tassert.CheckError(t, api.DeleteObject(baseParams, bckTo, manifestObj))
+}
+
+// TestETLMultiObjVerifyChecksum runs an MD5 offline-ETL stage - which rewrites
+// every object's bytes - together with VerifyChecksum and checks that the
+// xaction does NOT reject the copy: `VerifyChecksum`'s src/dst equality check
+// only applies to a plain (stage-less) copy, where dst bytes are expected to
+// equal src bytes. Once an ETL stage transforms the content, the recomputed
+// dst checksum is expected to differ from the source's, so there's nothing
+// meaningful to compare it against; the object must land live in ToBck.
+func TestETLMultiObjVerifyChecksum(t *testing.T) {
+	tools.CheckSkip(t, tools.SkipTestArgs{RequiredDeployment: tools.ClusterTypeK8s})
+	tetl.CheckNoRunningETLContainers(t, baseParams)
+
+	const (
+		transformer = tetl.MD5
+		etlCommType = etl.Hpush
+		objSize     = cos.KiB
+		cksumType   = cos.ChecksumMD5
+		objName     = "test/verify-0000"
+	)
+	var (
+		proxyURL   = tools.RandomProxyURL(t)
+		baseParams = tools.BaseAPIParams(proxyURL)
+		bck        = cmn.Bck{Name: "etlverify", Provider: apc.AIS}
+		toBck      = cmn.Bck{Name: "etlverify-out-" + trand.String(5), Provider: apc.AIS}
+	)
+
+	tools.CreateBucketWithCleanup(t, proxyURL, bck, nil)
+	tools.CreateBucketWithCleanup(t, proxyURL, toBck, nil)
+
+	r, _ := readers.NewRandReader(objSize, cksumType)
+	err := api.PutObject(api.PutObjectArgs{
+		BaseParams: baseParams,
+		Bck:        bck,
+		Object:     objName,
+		Reader:     r,
+		Size:       objSize,
+	})
+	tassert.CheckFatal(t, err)
+
+	uuid := tetl.Init(t, baseParams, transformer, etlCommType)
+	t.Cleanup(func() { tetl.StopAndDeleteETL(t, baseParams, uuid) })
+
+	msg := cmn.TCObjsMsg{
+		TCBMsg:         apc.TCBMsg{ID: uuid},
+		SelectObjsMsg:  cmn.SelectObjsMsg{ObjNames: []string{objName}},
+		ToBck:          toBck,
+		VerifyChecksum: true,
+	}
+	xactID, err := api.ETLMultiObj(baseParams, bck, msg)
+	tassert.CheckFatal(t, err)
+
+	wargs := api.XactReqArgs{ID: xactID, Kind: apc.ActETLObjects}
+	err = api.WaitForXactionIdle(baseParams, wargs)
+	tassert.CheckFatal(t, err)
+
+	_, err = api.HeadObject(baseParams, toBck, objName, false)
+	tassert.CheckFatal(t, err)
+}
+
 func TestCopyMultiObj(t *testing.T) {
 	runProviderTests(t, func(t *testing.T, bck *cluster.Bck) {
 		testCopyMobj(t, bck)
@@ -231,6 +385,84 @@ func TestETLMultiObj(t *testing.T) {
 	}
 }
 
+// TestETLMultiObjPipeline chains two transformers (both tetl.MD5 instances,
+// initialized as separate ETLs) into a single offline-ETL xaction: stage 1's
+// output streams directly into stage
+// 2's input on the target, so the client never sees (and the cluster never
+// writes to disk) the intermediate decoded object.
+func TestETLMultiObjPipeline(t *testing.T) {
+	tools.CheckSkip(t, tools.SkipTestArgs{RequiredDeployment: tools.ClusterTypeK8s})
+	tetl.CheckNoRunningETLContainers(t, baseParams)
+
+	const (
+		objCnt      = 50
+		copyCnt     = 20
+		rangeStart  = 10
+		transformer = tetl.MD5
+		etlCommType = etl.Hpush
+		objSize     = cos.KiB
+		cksumType   = cos.ChecksumMD5
+	)
+	var (
+		proxyURL   = tools.RandomProxyURL(t)
+		baseParams = tools.BaseAPIParams(proxyURL)
+
+		bck   = cmn.Bck{Name: "etlpipeline", Provider: apc.AIS}
+		toBck = cmn.Bck{Name: "etlpipeline-out-" + trand.String(5), Provider: apc.AIS}
+	)
+
+	tools.CreateBucketWithCleanup(t, proxyURL, bck, nil)
+	tools.CreateBucketWithCleanup(t, proxyURL, toBck, nil)
+
+	for i := 0; i < objCnt; i++ {
+		r, _ := readers.NewRandReader(objSize, cksumType)
+		err := api.PutObject(api.PutObjectArgs{
+			BaseParams: baseParams,
+			Bck:        bck,
+			Object:     fmt.Sprintf("test/a-%04d", i),
+			Reader:     r,
+			Size:       objSize,
+		})
+		tassert.CheckFatal(t, err)
+	}
+
+	decodeUUID := tetl.Init(t, baseParams, transformer, etlCommType)
+	t.Cleanup(func() { tetl.StopAndDeleteETL(t, baseParams, decodeUUID) })
+	hashUUID := tetl.Init(t, baseParams, transformer, etlCommType)
+	t.Cleanup(func() { tetl.StopAndDeleteETL(t, baseParams, hashUUID) })
+
+	requestTimeout := 30 * time.Second
+	fileRange := "test/a-" + fmt.Sprintf("{%04d..%04d}", rangeStart, rangeStart+copyCnt-1)
+	pt, err := cos.ParseBashTemplate(fileRange)
+	tassert.CheckFatal(t, err)
+	objList := pt.ToSlice()
+
+	msg := cmn.TCObjsPipelineMsg{
+		Stages: []apc.ETLStage{
+			{TCBMsg: apc.TCBMsg{ID: decodeUUID, RequestTimeout: cos.Duration(requestTimeout)}},
+			{TCBMsg: apc.TCBMsg{ID: hashUUID, RequestTimeout: cos.Duration(requestTimeout)}},
+		},
+		ToBck: toBck,
+	}
+	msg.SelectObjsMsg.Template = fileRange
+
+	tlog.Logf("Start chained offline ETL (%s -> %s) over %q\n", decodeUUID, hashUUID, fileRange)
+	xactID, err := api.ETLMultiObj(baseParams, bck, msg)
+	tassert.CheckFatal(t, err)
+
+	wargs := api.XactReqArgs{ID: xactID, Kind: apc.ActETLObjects}
+	err = api.WaitForXactionIdle(baseParams, wargs)
+	tassert.CheckFatal(t, err)
+
+	list, err := api.ListObjects(baseParams, toBck, nil, 0)
+	tassert.CheckFatal(t, err)
+	tassert.Errorf(t, len(list.Entries) == len(objList), "expected %d objects from chained offline ETL, got %d", len(objList), len(list.Entries))
+	for _, objName := range objList {
+		err := api.DeleteObject(baseParams, toBck, objName)
+		tassert.CheckError(t, err)
+	}
+}
+
 func testETLMultiObj(t *testing.T, uuid string, fromBck, toBck cmn.Bck, fileRange, opType string) {
 	pt, err := cos.ParseBashTemplate(fileRange)
 	tassert.CheckFatal(t, err)
@@ -0,0 +1,164 @@
+// Package store - see store.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/aistore/3rdparty/glog"
+	"github.com/hashicorp/consul/api"
+)
+
+type (
+	consulBackend struct {
+		cli *api.Client
+	}
+	consulSession struct {
+		cli     *api.Client
+		id      string
+		stopCh  chan struct{}
+		closeFn sync.Once
+	}
+)
+
+func newConsulBackend(cfg Config) (Backend, error) {
+	acfg := api.DefaultConfig()
+	if len(cfg.Endpoints) > 0 {
+		acfg.Address = cfg.Endpoints[0]
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" || cfg.CAFile != "" {
+		acfg.TLSConfig = api.TLSConfig{
+			CertFile: cfg.CertFile,
+			KeyFile:  cfg.KeyFile,
+			CAFile:   cfg.CAFile,
+		}
+	}
+	cli, err := api.NewClient(acfg)
+	if err != nil {
+		return nil, err
+	}
+	return &consulBackend{cli: cli}, nil
+}
+
+func (b *consulBackend) NewSession(_ context.Context, ttl time.Duration) (Session, error) {
+	id, _, err := b.cli.Session().Create(&api.SessionEntry{
+		TTL:      ttl.String(),
+		Behavior: api.SessionBehaviorDelete,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	sess := &consulSession{cli: b.cli, id: id, stopCh: make(chan struct{})}
+	// A Consul TTL session self-destructs (releasing any lock acquired via it)
+	// once ttl elapses with no renewal - unlike etcd's concurrency.NewSession,
+	// which renews its lease internally. RenewPeriodic keeps it alive for as
+	// long as the session is held; Close stops the goroutine and destroys it.
+	go func() {
+		if err := b.cli.Session().RenewPeriodic(ttl.String(), id, nil, sess.stopCh); err != nil {
+			glog.Errorf("consul: session %s renew stopped: %v", id, err)
+		}
+	}()
+	return sess, nil
+}
+
+func (b *consulBackend) AcquireLock(_ context.Context, sess Session, key string) (bool, error) {
+	acquired, _, err := b.cli.KV().Acquire(&api.KVPair{
+		Key:     key,
+		Session: sess.(*consulSession).id,
+	}, nil)
+	return acquired, err
+}
+
+func (b *consulBackend) Put(_ context.Context, key string, value []byte) (int64, error) {
+	_, err := b.cli.KV().Put(&api.KVPair{Key: key, Value: value}, nil)
+	if err != nil {
+		return 0, err
+	}
+	_, meta, err := b.cli.KV().Get(key, nil)
+	if err != nil || meta == nil {
+		return 0, err
+	}
+	return int64(meta.LastIndex), nil
+}
+
+func (b *consulBackend) CAS(_ context.Context, key string, value []byte, prevRev int64) (int64, error) {
+	ok, _, err := b.cli.KV().CAS(&api.KVPair{
+		Key:         key,
+		Value:       value,
+		ModifyIndex: uint64(prevRev),
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrCASConflict
+	}
+	_, meta, err := b.cli.KV().Get(key, nil)
+	if err != nil || meta == nil {
+		return 0, err
+	}
+	return int64(meta.LastIndex), nil
+}
+
+func (b *consulBackend) Get(_ context.Context, key string) ([]byte, int64, error) {
+	kv, meta, err := b.cli.KV().Get(key, nil)
+	if err != nil || kv == nil {
+		return nil, 0, err
+	}
+	return kv.Value, int64(meta.LastIndex), nil
+}
+
+// consulWatchRetryDelay throttles the retry loop below after a transient
+// KV().Get error (e.g. a blip in cluster connectivity) - without it a
+// tight retry loop would hammer the Consul agent.
+const consulWatchRetryDelay = time.Second
+
+// Watch polls (long, via Consul's blocking-query support) rather than pushes;
+// callers treat both backends identically through the `WatchEvent` channel.
+// A transient Get error is reported on the channel and retried rather than
+// ending the watch - unlike etcd's server-push Watch, a blocking query can
+// fail for reasons (agent restart, brief network blip) that have nothing to
+// do with the key's actual state, and this is exactly the kind of flaky
+// network the external-store feature is meant to tolerate.
+func (b *consulBackend) Watch(ctx context.Context, key string) (<-chan WatchEvent, error) {
+	out := make(chan WatchEvent, 8)
+	go func() {
+		defer close(out)
+		var lastIdx uint64
+		for ctx.Err() == nil {
+			qopts := (&api.QueryOptions{
+				WaitIndex: lastIdx,
+				WaitTime:  30 * time.Second,
+			}).WithContext(ctx)
+			kv, meta, err := b.cli.KV().Get(key, qopts)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				out <- WatchEvent{Err: err}
+				time.Sleep(consulWatchRetryDelay)
+				continue
+			}
+			if meta != nil && meta.LastIndex != lastIdx {
+				lastIdx = meta.LastIndex
+				if kv != nil {
+					out <- WatchEvent{Value: kv.Value, Rev: int64(meta.LastIndex)}
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (b *consulBackend) Close() error { return nil }
+
+func (s *consulSession) ID() string { return s.id }
+func (s *consulSession) Close(_ context.Context) error {
+	s.closeFn.Do(func() { close(s.stopCh) })
+	_, err := s.cli.Session().Destroy(s.id, nil)
+	return err
+}
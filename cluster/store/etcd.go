@@ -0,0 +1,145 @@
+// Package store - see store.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package store
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+type (
+	etcdBackend struct {
+		cli *clientv3.Client
+	}
+	etcdSession struct {
+		s *concurrency.Session
+	}
+)
+
+func newEtcdBackend(cfg Config) (Backend, error) {
+	etcdCfg := clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		DialTimeout: cfg.Timeout,
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" || cfg.CAFile != "" {
+		tlsCfg, err := loadTLS(cfg)
+		if err != nil {
+			return nil, err
+		}
+		etcdCfg.TLS = tlsCfg
+	}
+	cli, err := clientv3.New(etcdCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &etcdBackend{cli: cli}, nil
+}
+
+func loadTLS(cfg Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		tlsCfg.RootCAs = pool
+	}
+	return tlsCfg, nil
+}
+
+func (b *etcdBackend) NewSession(_ context.Context, ttl time.Duration) (Session, error) {
+	s, err := concurrency.NewSession(b.cli, concurrency.WithTTL(int(ttl.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	return &etcdSession{s: s}, nil
+}
+
+func (b *etcdBackend) AcquireLock(ctx context.Context, sess Session, key string) (bool, error) {
+	mu := concurrency.NewMutex(sess.(*etcdSession).s, key)
+	if err := mu.TryLock(ctx); err != nil {
+		if err == concurrency.ErrLocked {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *etcdBackend) Put(ctx context.Context, key string, value []byte) (int64, error) {
+	resp, err := b.cli.Put(ctx, key, string(value))
+	if err != nil {
+		return 0, err
+	}
+	return resp.Header.Revision, nil
+}
+
+func (b *etcdBackend) CAS(ctx context.Context, key string, value []byte, prevRev int64) (int64, error) {
+	var cmp clientv3.Cmp
+	if prevRev == 0 {
+		cmp = clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+	} else {
+		cmp = clientv3.Compare(clientv3.ModRevision(key), "=", prevRev)
+	}
+	txn := b.cli.Txn(ctx).If(cmp).Then(clientv3.OpPut(key, string(value)))
+	resp, err := txn.Commit()
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Succeeded {
+		return 0, ErrCASConflict
+	}
+	return resp.Header.Revision, nil
+}
+
+func (b *etcdBackend) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	resp, err := b.cli.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+	kv := resp.Kvs[0]
+	return kv.Value, kv.ModRevision, nil
+}
+
+func (b *etcdBackend) Watch(ctx context.Context, key string) (<-chan WatchEvent, error) {
+	out := make(chan WatchEvent, 8)
+	wch := b.cli.Watch(ctx, key)
+	go func() {
+		defer close(out)
+		for resp := range wch {
+			if err := resp.Err(); err != nil {
+				out <- WatchEvent{Err: err}
+				continue
+			}
+			for _, ev := range resp.Events {
+				out <- WatchEvent{Value: ev.Kv.Value, Rev: ev.Kv.ModRevision}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (b *etcdBackend) Close() error { return b.cli.Close() }
+
+func (s *etcdSession) ID() string { return s.s.Lease().String() }
+func (s *etcdSession) Close(_ context.Context) error { return s.s.Close() }
@@ -0,0 +1,97 @@
+// Package store abstracts an external, strongly-consistent key-value store
+// (etcd, Consul) that an AIS proxy may optionally delegate Smap/BMD
+// persistence and primary-election to, in place of the default gossip-based
+// metasyncer dance (see `ais/earlystart.go`).
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Backend selector, see `cmn.Config.Store.Backend`.
+const (
+	Internal = "internal" // default: gossip + metasyncer, no external dependency
+	Etcd     = "etcdv3"
+	Consul   = "consul"
+)
+
+var ErrCASConflict = errors.New("store: compare-and-swap conflict")
+
+type (
+	// Config carries the subset of `cmn.Config` needed to dial an external store.
+	Config struct {
+		Backend   string   // Internal | Etcd | Consul
+		Endpoints []string // "host:port" pairs
+		CertFile  string
+		KeyFile   string
+		CAFile    string
+		Timeout   time.Duration
+	}
+
+	// Session represents a live lease/session used for both liveness (TTL)
+	// and leader-election locking; closing it releases any lock held via it.
+	Session interface {
+		ID() string
+		Close(ctx context.Context) error
+	}
+
+	// Backend is the minimal surface `ais/earlystart.go` needs from an external
+	// strongly-consistent store: CAS'd revisions for Smap/BMD, a watch for
+	// followers to pick up changes pushed by the elected primary, and a lock
+	// used to decide who _is_ primary.
+	Backend interface {
+		// NewSession opens a TTL-bound session (etcd lease / Consul session);
+		// the session must be renewed by the backend for as long as it's held.
+		NewSession(ctx context.Context, ttl time.Duration) (Session, error)
+
+		// AcquireLock makes a single, non-blocking attempt to become the holder
+		// of `key` (typically "/ais/<cluster-uuid>/primary") under `sess`;
+		// returns true if this process acquired the lock and false, with a nil
+		// error, if another session already holds it.
+		AcquireLock(ctx context.Context, sess Session, key string) (bool, error)
+
+		// Put writes `value` unconditionally, returning the new revision.
+		Put(ctx context.Context, key string, value []byte) (rev int64, err error)
+
+		// CAS writes `value` iff the key's current revision equals `prevRev`
+		// (prevRev == 0 means "key must not exist"); returns ErrCASConflict
+		// otherwise. Used to avoid clobbering a concurrently-written revision
+		// of Smap/BMD.
+		CAS(ctx context.Context, key string, value []byte, prevRev int64) (rev int64, err error)
+
+		// Get returns the current value and revision of `key` (rev == 0 if absent).
+		Get(ctx context.Context, key string) (value []byte, rev int64, err error)
+
+		// Watch streams every subsequent revision of `key` until ctx is done;
+		// used by non-primaries to apply Smap/BMD updates the elected primary CAS's in.
+		Watch(ctx context.Context, key string) (<-chan WatchEvent, error)
+
+		Close() error
+	}
+
+	WatchEvent struct {
+		Value []byte
+		Rev   int64
+		Err   error
+	}
+)
+
+// New dials the backend selected by `cfg.Backend`; a nil, nil return means
+// "internal" - i.e., the caller should fall back to the default gossip path.
+func New(cfg Config) (Backend, error) {
+	switch cfg.Backend {
+	case "", Internal:
+		return nil, nil
+	case Etcd:
+		return newEtcdBackend(cfg)
+	case Consul:
+		return newConsulBackend(cfg)
+	default:
+		return nil, errors.New("store: unknown backend " + cfg.Backend)
+	}
+}
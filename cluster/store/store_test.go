@@ -0,0 +1,40 @@
+// Package store - see store.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package store
+
+import "testing"
+
+func TestNewInternalIsNilNil(t *testing.T) {
+	for _, backend := range []string{"", Internal} {
+		b, err := New(Config{Backend: backend})
+		if b != nil || err != nil {
+			t.Errorf("New(Backend=%q) = (%v, %v), want (nil, nil)", backend, b, err)
+		}
+	}
+}
+
+func TestNewUnknownBackendErrors(t *testing.T) {
+	b, err := New(Config{Backend: "zookeeper"})
+	if b != nil || err == nil {
+		t.Errorf("New(Backend=zookeeper) = (%v, %v), want (nil, non-nil error)", b, err)
+	}
+}
+
+func TestNewEtcdAndConsulDial(t *testing.T) {
+	// clientv3.New/api.NewClient only construct a client; they don't dial
+	// synchronously, so this doesn't require a live etcd/Consul endpoint.
+	for _, backend := range []string{Etcd, Consul} {
+		b, err := New(Config{Backend: backend, Endpoints: []string{"127.0.0.1:0"}})
+		if err != nil {
+			t.Fatalf("New(Backend=%s) error: %v", backend, err)
+		}
+		if b == nil {
+			t.Fatalf("New(Backend=%s) returned a nil Backend", backend)
+		}
+		if err := b.Close(); err != nil {
+			t.Errorf("%s: Close: %v", backend, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package blobcache - see blobcache.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package blobcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Stats summarizes the cache, as shown by `ais cache stat`.
+type Stats struct {
+	Entries int
+	Bytes   int64
+}
+
+func Stat() (Stats, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Stats{}, err
+	}
+	var s Stats
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) == sidecarExt {
+			s.Entries++
+		} else if filepath.Ext(path) == "" {
+			s.Bytes += info.Size()
+		}
+		return nil
+	})
+	return s, err
+}
+
+type entryInfo struct {
+	key         string
+	blobPath    string
+	sidecarPath string
+	lockPath    string
+	meta        Meta
+	size        int64
+}
+
+func allEntries(dir string) ([]entryInfo, error) {
+	var out []entryInfo
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != sidecarExt {
+			return nil
+		}
+		k := filepath.Base(path)[:len(filepath.Base(path))-len(sidecarExt)]
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var m Meta
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil
+		}
+		blobPath, sidecarPath, lockPath := paths(dir, k)
+		fi, err := os.Stat(blobPath)
+		if err != nil {
+			return nil
+		}
+		out = append(out, entryInfo{key: k, blobPath: blobPath, sidecarPath: sidecarPath, lockPath: lockPath, meta: m, size: fi.Size()})
+		return nil
+	})
+	return out, err
+}
+
+// Prune evicts entries, oldest atime first, until the cache's total size is
+// at or under limitBytes. Returns the number of entries removed.
+func Prune(limitBytes int64) (int, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+	entries, err := allEntries(dir)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].meta.Atime.Before(entries[j].meta.Atime) })
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	removed := 0
+	for _, e := range entries {
+		if total <= limitBytes {
+			break
+		}
+		if err := withLock(e.lockPath, func() error {
+			_ = os.Remove(e.blobPath)
+			_ = os.Remove(e.sidecarPath)
+			return nil
+		}); err != nil {
+			continue
+		}
+		total -= e.size
+		removed++
+	}
+	return removed, nil
+}
+
+// Verify re-hashes every cached blob against its sidecar's recorded SHA256,
+// removing (and reporting) any entry that no longer matches - a cache
+// invalidated out-of-band, or corrupted on disk.
+func Verify() (corrupt []string, err error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := allEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		ok, err := verifyOne(e)
+		if err != nil || !ok {
+			corrupt = append(corrupt, e.meta.Bucket+"/"+e.meta.Object)
+			_ = withLock(e.lockPath, func() error {
+				_ = os.Remove(e.blobPath)
+				_ = os.Remove(e.sidecarPath)
+				return nil
+			})
+		}
+	}
+	return corrupt, nil
+}
+
+func verifyOne(e entryInfo) (bool, error) {
+	fh, err := os.Open(e.blobPath)
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fh); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(h.Sum(nil)) == e.meta.SHA256, nil
+}
@@ -0,0 +1,250 @@
+// Package blobcache implements a client-side cache for repeated GET/HEAD,
+// modeled on buildah's pkg/blobcache: objects live under '<key[:2]>/<key>',
+// where key is the SHA256 of "bucket/object" (the object's name, not its
+// content, so identical content under two names is stored twice), with a
+// JSON sidecar carrying the metadata needed to tell whether a cached copy is
+// still valid (version, etag, custom MD) and the content's own SHA256.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package blobcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Mode is the value of the CLI's `--cache` flag.
+type Mode string
+
+const (
+	On      Mode = "on"
+	Off     Mode = "off"
+	Refresh Mode = "refresh" // bypass the cache for reads, but still (re)populate it
+
+	sidecarExt = ".json"
+	lockExt    = ".lock"
+
+	DefaultSizeLimit = 10 << 30 // 10GiB, matches the documented `--cache-size=10GiB` default
+)
+
+// Meta is the sidecar JSON stored next to each cached blob.
+type Meta struct {
+	Bucket   string            `json:"bucket"`
+	Object   string            `json:"object"`
+	Version  string            `json:"version"`
+	ETag     string            `json:"etag"`
+	Size     int64             `json:"size"`
+	CustomMD map[string]string `json:"custom_md,omitempty"`
+	Atime    time.Time         `json:"atime"`
+	SHA256   string            `json:"sha256"`
+}
+
+// Dir is the cache root, '~/.cache/ais/blobs' per the repo's XDG-ish CLI
+// local-state convention (cf. cli/trust's '~/.config/ais/trust').
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".cache", "ais", "blobs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func key(bucket, object string) string {
+	sum := sha256.Sum256([]byte(bucket + "/" + object))
+	return hex.EncodeToString(sum[:])
+}
+
+func paths(dir, k string) (blob, sidecar, lock string) {
+	sub := filepath.Join(dir, k[:2])
+	return filepath.Join(sub, k), filepath.Join(sub, k+sidecarExt), filepath.Join(sub, k+lockExt)
+}
+
+// withLock serializes access to a single cache entry across concurrent CLI
+// invocations (e.g. two parallel `ais get` of the same object).
+func withLock(lockPath string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o755); err != nil {
+		return err
+	}
+	fl := flock.New(lockPath)
+	if err := fl.Lock(); err != nil {
+		return err
+	}
+	defer fl.Unlock()
+	return fn()
+}
+
+// Lookup returns the cached Meta and an open reader for bucket/object if
+// present, regardless of freshness - callers (Fresh) decide whether the
+// entry is still usable against the object's current version/etag.
+func Lookup(bucket, object string) (*Meta, io.ReadCloser, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, nil, err
+	}
+	k := key(bucket, object)
+	blobPath, sidecarPath, _ := paths(dir, k)
+
+	raw, err := os.ReadFile(sidecarPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var m Meta
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, nil, err
+	}
+	fh, err := os.Open(blobPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &m, fh, nil
+}
+
+// Fresh reports whether a cached entry still matches the object's current
+// version/etag as reported by HEAD.
+func Fresh(m *Meta, version, etag string) bool {
+	if etag != "" && m.ETag != "" {
+		return m.ETag == etag
+	}
+	return m.Version == version
+}
+
+// Touch bumps an entry's atime, driving the LRU eviction order used by Prune.
+func Touch(bucket, object string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	k := key(bucket, object)
+	_, sidecarPath, lockPath := paths(dir, k)
+	return withLock(lockPath, func() error {
+		raw, err := os.ReadFile(sidecarPath)
+		if err != nil {
+			return err
+		}
+		var m Meta
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return err
+		}
+		m.Atime = time.Now()
+		return writeSidecar(sidecarPath, &m)
+	})
+}
+
+// Store tees src into the cache while returning a reader that yields the
+// same bytes to the caller's actual destination, so a cache miss costs one
+// network read instead of two.
+func Store(bucket, object, version, etag string, size int64, customMD map[string]string, src io.Reader) (io.Reader, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	k := key(bucket, object)
+	blobPath, sidecarPath, lockPath := paths(dir, k)
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0o755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(blobPath), k+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	tee := io.TeeReader(src, io.MultiWriter(tmp, h))
+
+	// The caller drains `tee` as it writes to the real destination; once
+	// drained, finalize() below atomically publishes the blob + sidecar.
+	return &storeReader{
+		tee: tee, tmp: tmp, blobPath: blobPath, sidecarPath: sidecarPath, lockPath: lockPath,
+		meta: Meta{Bucket: bucket, Object: object, Version: version, ETag: etag, Size: size, CustomMD: customMD, Atime: time.Now()},
+		h:    h,
+	}, nil
+}
+
+type storeReader struct {
+	tee         io.Reader
+	tmp         *os.File
+	blobPath    string
+	sidecarPath string
+	lockPath    string
+	meta        Meta
+	h           interface{ Sum([]byte) []byte }
+	done        bool
+}
+
+func (r *storeReader) Read(p []byte) (int, error) {
+	n, err := r.tee.Read(p)
+	if err == io.EOF && !r.done {
+		r.done = true
+		if finErr := r.finalize(); finErr != nil {
+			return n, finErr
+		}
+	}
+	return n, err
+}
+
+func (r *storeReader) finalize() error {
+	defer r.tmp.Close()
+	r.meta.SHA256 = hex.EncodeToString(r.h.Sum(nil))
+	return withLock(r.lockPath, func() error {
+		if err := os.Rename(r.tmp.Name(), r.blobPath); err != nil {
+			return err
+		}
+		return writeSidecar(r.sidecarPath, &r.meta)
+	})
+}
+
+func writeSidecar(path string, m *Meta) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// Remove drops bucket/object from the cache. Verify and Prune remove their
+// entries directly and do not go through it.
+func Remove(bucket, object string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	k := key(bucket, object)
+	blobPath, sidecarPath, lockPath := paths(dir, k)
+	return withLock(lockPath, func() error {
+		_ = os.Remove(blobPath)
+		_ = os.Remove(sidecarPath)
+		return nil
+	})
+}
+
+// Size returns the cache's total on-disk size (sum of blob file sizes).
+func Size() (int64, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Ext(path) != "" {
+			return nil
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+var errNotCached = fmt.Errorf("blobcache: entry not found")
@@ -0,0 +1,140 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file handles CLI commands for the local blob/metadata cache (see cli/blobcache).
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmd/cli/cli/blobcache"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/urfave/cli"
+)
+
+const (
+	commandCache      = "cache"
+	commandCachePrune = "prune"
+	commandCacheStat  = "stat"
+	commandCacheVerify = "verify"
+)
+
+var (
+	cacheFlag = cli.StringFlag{Name: "cache", Usage: "client-side cache mode: 'on' (default), 'off', or 'refresh'", Value: string(blobcache.On)}
+	cacheSizeFlag = cli.StringFlag{Name: "cache-size", Usage: "cache size limit, SI suffix allowed", Value: "10GiB"}
+
+	cacheCmd = cli.Command{
+		Name:  commandCache,
+		Usage: "manage the local client-side blob/metadata cache used by 'ais get'/'ais cat'",
+		Subcommands: []cli.Command{
+			{
+				Name:   commandCachePrune,
+				Usage:  "evict least-recently-used entries down to '--cache-size'",
+				Flags:  []cli.Flag{cacheSizeFlag},
+				Action: cachePruneHandler,
+			},
+			{
+				Name:   commandCacheStat,
+				Usage:  "show cache entry count and total size",
+				Flags:  []cli.Flag{formatFlag, formatTemplateFlag},
+				Action: cacheStatHandler,
+			},
+			{
+				Name:   commandCacheVerify,
+				Usage:  "re-hash every cached entry, evicting any that no longer match",
+				Action: cacheVerifyHandler,
+			},
+		},
+	}
+)
+
+func cachePruneHandler(c *cli.Context) error {
+	limit, err := cos.ParseSize(parseStrFlag(c, cacheSizeFlag))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", qflprn(cacheSizeFlag), err)
+	}
+	n, err := blobcache.Prune(limit)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "pruned %d entries\n", n)
+	return nil
+}
+
+func cacheStatHandler(c *cli.Context) error {
+	s, err := blobcache.Stat()
+	if err != nil {
+		return err
+	}
+	return outputResult(c, &cacheStatRow{Entries: s.Entries, Bytes: s.Bytes})
+}
+
+type cacheStatRow struct {
+	Entries int   `col:"ENTRIES"`
+	Bytes   int64 `col:"BYTES"`
+}
+
+func cacheVerifyHandler(c *cli.Context) error {
+	corrupt, err := blobcache.Verify()
+	if err != nil {
+		return err
+	}
+	if len(corrupt) == 0 {
+		fmt.Fprintln(c.App.Writer, "OK: all cached entries verified")
+		return nil
+	}
+	fmt.Fprintf(c.App.Writer, "evicted %d corrupt/stale entries:\n", len(corrupt))
+	for _, name := range corrupt {
+		fmt.Fprintf(c.App.Writer, "  %s\n", name)
+	}
+	return nil
+}
+
+// cachedGet implements the on/off/refresh semantics shared by `getHandler`
+// and `catHandler` (object_hdlr.go): on a cache hit (fresh version/etag) it
+// streams from the local cache; on a miss it HEADs+GETs as usual, teeing
+// the response into the cache.
+func cachedGet(bck cmn.Bck, objName string, mode blobcache.Mode, dst io.Writer) (err error) {
+	if mode == blobcache.Off {
+		_, err = api.GetObject(apiBP, bck, objName, api.GetObjectInput{Writer: dst})
+		return err
+	}
+
+	props, err := api.HeadObject(apiBP, bck, objName, false)
+	if err != nil {
+		return err
+	}
+	etag := props.Checksum().Value()
+
+	if mode == blobcache.On {
+		if m, rc, err := blobcache.Lookup(bck.Name, objName); err == nil {
+			defer rc.Close()
+			if blobcache.Fresh(m, props.Version(), etag) {
+				_ = blobcache.Touch(bck.Name, objName)
+				_, err := io.Copy(dst, rc)
+				return err
+			}
+		}
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, getErr := api.GetObject(apiBP, bck, objName, api.GetObjectInput{Writer: pw})
+		pw.CloseWithError(getErr)
+	}()
+	go func() {
+		stored, storeErr := blobcache.Store(bck.Name, objName, props.Version(), etag, props.Size, props.CustomMD(), pr)
+		if storeErr != nil {
+			done <- storeErr
+			return
+		}
+		_, copyErr := io.Copy(dst, stored)
+		done <- copyErr
+	}()
+	return <-done
+}
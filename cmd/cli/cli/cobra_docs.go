@@ -0,0 +1,32 @@
+// Package cli - see cobra_root.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+var (
+	genManCmd = &cobra.Command{
+		Use:    "gen-man DIR",
+		Short:  "generate man pages for the entire command tree into DIR",
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doc.GenManTree(RootCmd, &doc.GenManHeader{Title: "AIS", Section: "1"}, args[0])
+		},
+	}
+
+	genMDCmd = &cobra.Command{
+		Use:    "gen-md DIR",
+		Short:  "generate Markdown docs for the entire command tree into DIR",
+		Args:   cobra.ExactArgs(1),
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return doc.GenMarkdownTree(RootCmd, args[0])
+		},
+	}
+)
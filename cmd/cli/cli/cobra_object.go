@@ -0,0 +1,63 @@
+// Package cli - see cobra_root.go
+//
+// mvObjectCobraCmd is the first leaf command ported from urfave/cli to
+// cobra/pflag, standing in for `mvObjectHandler` while the rest of the
+// command tree migrates incrementally.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/spf13/cobra"
+)
+
+var mvObjectCobraCmd = &cobra.Command{
+	Use:   "mv BUCKET/OBJECT BUCKET/NEW_OBJECT",
+	Short: "move/rename object",
+	Args:  cobra.ExactArgs(2),
+	RunE:  mvObjectCobraHandler,
+}
+
+func mvObjectCobraHandler(cmd *cobra.Command, args []string) error {
+	oldObjFull, newObj := args[0], args[1]
+
+	bck, oldObj, err := parseBckObjURICobra(oldObjFull)
+	if err != nil {
+		return err
+	}
+	if oldObj == "" {
+		return fmt.Errorf("no object specified in %q", oldObjFull)
+	}
+	if bck.Name == "" {
+		return fmt.Errorf("no bucket specified for object %q", oldObj)
+	}
+	if !bck.IsAIS() {
+		return fmt.Errorf("provider %q not supported", bck.Provider)
+	}
+
+	if bckDst, objDst, err := parseBckObjURICobra(newObj); err == nil && bckDst.Name != "" {
+		if !bckDst.Equal(&bck) {
+			return fmt.Errorf("moving an object to another bucket(%s) is not supported", bckDst)
+		}
+		newObj = objDst
+	}
+	if newObj == oldObj {
+		return fmt.Errorf("source and destination are the same object")
+	}
+
+	if err := api.RenameObject(apiBP, bck, oldObj, newObj); err != nil {
+		return err
+	}
+	return outputResultCobra(cmd, &moveResult{From: oldObj, To: newObj})
+}
+
+// parseBckObjURICobra is parseBckObjURI's cobra counterpart: it doesn't need
+// a *cli.Context, since 'bucket/object' URIs don't depend on any flag.
+func parseBckObjURICobra(uri string) (bck cmn.Bck, objName string, err error) {
+	return cmn.ParseBckObjectURI(uri)
+}
@@ -0,0 +1,145 @@
+// Package download renders a downloader job's live progress events as
+// Docker-pull-style layered bars: one line per object, redrawn in place as
+// `downloader.DlProgressEvent`s arrive off `api.DownloadWatch`.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package download
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/NVIDIA/aistore/downloader"
+)
+
+// line is one object's current row in the layered display.
+type line struct {
+	objName string
+	status  string
+	delta   int64
+	total   int64
+	speed   float64
+	retry   int
+	err     string
+	done    bool
+}
+
+func (l *line) render() string {
+	switch l.status {
+	case "started":
+		return fmt.Sprintf("%-40s  waiting", l.objName)
+	case "transferring":
+		bar := fmt.Sprintf("%s  %s/s", progressBar(l.delta, l.total), humanSize(l.speed)+"ps")
+		if l.retry > 0 {
+			bar += fmt.Sprintf("  (retry %d)", l.retry)
+		}
+		return fmt.Sprintf("%-40s  %s", l.objName, bar)
+	case "finished":
+		return fmt.Sprintf("%-40s  done", l.objName)
+	case "failed":
+		return fmt.Sprintf("%-40s  failed: %s", l.objName, l.err)
+	default:
+		return l.objName
+	}
+}
+
+func progressBar(cur, total int64) string {
+	const width = 20
+	if total <= 0 {
+		return strings.Repeat("-", width)
+	}
+	filled := int(float64(width) * float64(cur) / float64(total))
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("=", filled) + strings.Repeat("-", width-filled)
+}
+
+func humanSize(bps float64) string {
+	const unit = 1024.0
+	if bps < unit {
+		return fmt.Sprintf("%.0fB", bps)
+	}
+	div, exp := unit, 0
+	for n := bps / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", bps/div, "KMGTPE"[exp])
+}
+
+// Renderer tracks every object's latest line and redraws the whole block to
+// w each time Apply is called, the same terminal-redraw approach a
+// `docker pull`'s layer list uses - simpler than a cursor-addressed partial
+// update and good enough for a CLI that isn't also a TUI.
+type Renderer struct {
+	w     io.Writer
+	lines map[string]*line
+	drawn int // number of lines written on the previous Apply, so the next one can move the cursor back up
+}
+
+func NewRenderer(w io.Writer) *Renderer {
+	return &Renderer{w: w, lines: make(map[string]*line)}
+}
+
+// Apply folds one DlProgressEvent into the renderer's per-object state and
+// redraws the full block.
+func (r *Renderer) Apply(e downloader.DlProgressEvent) {
+	l, ok := r.lines[e.ObjName]
+	if !ok {
+		l = &line{objName: e.ObjName}
+		r.lines[e.ObjName] = l
+	}
+	switch e.Kind {
+	case downloader.WatchStarted:
+		l.status = "started"
+		l.retry = e.Retry
+	case downloader.WatchTransferred:
+		l.status = "transferring"
+		l.delta += e.Delta
+		l.total = e.Total
+		l.speed = e.SpeedBps
+	case downloader.WatchTaskFinished:
+		l.status = "finished"
+		l.done = true
+	case downloader.WatchTaskFailed:
+		l.status = "failed"
+		l.err = e.Err
+		l.retry = e.Retry
+		l.done = true
+	case downloader.WatchJobDone:
+		// nothing per-object to update; Apply's caller typically stops
+		// reading the channel after this event.
+		return
+	}
+	r.redraw()
+}
+
+func (r *Renderer) redraw() {
+	if r.drawn > 0 {
+		fmt.Fprintf(r.w, "\033[%dA", r.drawn) // move cursor back up over the previous block
+	}
+	names := make([]string, 0, len(r.lines))
+	for name := range r.lines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(r.w, "\033[2K%s\n", r.lines[name].render()) // \033[2K clears the line before rewriting it
+	}
+	r.drawn = len(names)
+}
+
+// Done reports whether every object this Renderer has seen has reached a
+// terminal state (finished or failed).
+func (r *Renderer) Done() bool {
+	for _, l := range r.lines {
+		if !l.done {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file handles CLI commands for the downloader job subsystem (see cli/download).
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"fmt"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmd/cli/cli/download"
+	"github.com/NVIDIA/aistore/downloader"
+	"github.com/urfave/cli"
+)
+
+const (
+	commandDownload      = "download"
+	commandDownloadWatch = "watch"
+)
+
+var (
+	downloadCmd = cli.Command{
+		Name:  commandDownload,
+		Usage: "manage downloader jobs",
+		Subcommands: []cli.Command{
+			{
+				Name:      commandDownloadWatch,
+				Usage:     "render a running download job's live progress as Docker-pull-style layered bars",
+				ArgsUsage: "JOB_ID",
+				Action:    downloadWatchHandler,
+			},
+		},
+	}
+)
+
+// downloadWatchHandler streams api.DownloadWatch(apiBP, id) into a
+// download.Renderer until the job's final WatchJobDone event, replacing the
+// `ais download status --refresh` poll loop with a push-based one.
+func downloadWatchHandler(c *cli.Context) error {
+	id := c.Args().First()
+	if id == "" {
+		return fmt.Errorf("missing %s argument", "JOB_ID")
+	}
+
+	events, err := api.DownloadWatch(apiBP, id)
+	if err != nil {
+		return err
+	}
+
+	r := download.NewRenderer(c.App.Writer)
+	for e := range events {
+		r.Apply(e)
+		if e.Kind == downloader.WatchJobDone {
+			break
+		}
+	}
+	if !r.Done() {
+		return fmt.Errorf("download watch for %s ended before every object reached a terminal state", id)
+	}
+	return nil
+}
+
+// `api.DownloadWatch` hits a new proxy endpoint that upgrades to WebSocket
+// (or falls back to SSE for an `Accept: text/event-stream` client) and
+// returns `<-chan downloader.DlProgressEvent` - one `watchAggregator`
+// (downloader/watch.go) per target, fanned in and re-sequenced by the proxy
+// the same way `api.DownloadEvents`'s raw stream already is (see the doc
+// comment at the bottom of downloader/events.go).
@@ -0,0 +1,52 @@
+// Package formats implements pluggable, scriptable rendering of CLI command
+// results - json/yaml for machine consumption, table for humans, and
+// text/template for everything in between.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package formats
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+)
+
+const (
+	JSON     = "json"
+	YAML     = "yaml"
+	Table    = "table"
+	Template = "template"
+
+	Default = Table
+)
+
+// Formatter renders v (typically a struct or a slice of structs returned by
+// an API call) to w. Implementations must not assume v's concrete type.
+type Formatter interface {
+	Format(v any, w io.Writer) error
+}
+
+// New resolves the `--format` flag value to a Formatter. tmpl is the
+// companion `--template` value and is only consulted when format == Template.
+func New(format, tmpl string) (Formatter, error) {
+	switch format {
+	case "", Default:
+		return &tableFormatter{}, nil
+	case JSON:
+		return &jsonFormatter{}, nil
+	case YAML:
+		return &yamlFormatter{}, nil
+	case Template:
+		if tmpl == "" {
+			return nil, fmt.Errorf("--format=template requires --template")
+		}
+		t, err := template.New("cli").Funcs(templateFuncs).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --template: %v", err)
+		}
+		return &templateFormatter{t: t}, nil
+	default:
+		return nil, fmt.Errorf("unknown --format %q (expecting one of: %s, %s, %s, %s)", format, JSON, YAML, Table, Template)
+	}
+}
@@ -0,0 +1,31 @@
+// Package formats - see formats.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package formats
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+type jsonFormatter struct{}
+
+func (*jsonFormatter) Format(v any, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+type yamlFormatter struct{}
+
+func (*yamlFormatter) Format(v any, w io.Writer) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
@@ -0,0 +1,95 @@
+// Package formats - see formats.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package formats
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+)
+
+// The `col` struct tag drives both the header text and column order, e.g.:
+//
+//	type entry struct {
+//	    Name string `col:"NAME"`
+//	    Size int64  `col:"SIZE"`
+//	}
+const tableTag = "col"
+
+type tableFormatter struct{}
+
+func (*tableFormatter) Format(v any, w io.Writer) error {
+	rows, headers, err := flatten(v)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(headers) > 0 {
+		fmt.Fprintln(tw, joinTabs(headers))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, joinTabs(row))
+	}
+	return tw.Flush()
+}
+
+// flatten turns v - a struct, or a slice/array of structs - into a table of
+// string cells plus the column headers taken from `col` struct tags. A
+// struct with no tagged fields falls back to one row with its Stringer (or
+// "%v") output and no header.
+func flatten(v any) (rows [][]string, headers []string, err error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			row, hdrs, ok := structRow(rv.Index(i))
+			if !ok {
+				rows = append(rows, []string{fmt.Sprintf("%v", rv.Index(i).Interface())})
+				continue
+			}
+			headers, rows = hdrs, append(rows, row)
+		}
+	case reflect.Struct:
+		row, hdrs, ok := structRow(rv)
+		if !ok {
+			rows = [][]string{{fmt.Sprintf("%v", v)}}
+			break
+		}
+		headers, rows = hdrs, [][]string{row}
+	default:
+		rows = [][]string{{fmt.Sprintf("%v", v)}}
+	}
+	return rows, headers, nil
+}
+
+func structRow(rv reflect.Value) (row, headers []string, ok bool) {
+	rv = reflect.Indirect(rv)
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, has := f.Tag.Lookup(tableTag)
+		if !has {
+			continue
+		}
+		headers = append(headers, tag)
+		row = append(row, fmt.Sprintf("%v", rv.Field(i).Interface()))
+	}
+	return row, headers, len(headers) > 0
+}
+
+func joinTabs(cells []string) string {
+	s := ""
+	for i, c := range cells {
+		if i > 0 {
+			s += "\t"
+		}
+		s += c
+	}
+	return s
+}
@@ -0,0 +1,73 @@
+// Package formats - see formats.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package formats
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var templateFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"humanBytes": humanBytes,
+	"rfc3339":    rfc3339,
+}
+
+// humanBytes renders n (bytes, as any integer kind) using IEC units, e.g.
+// 1536 -> "1.50KiB".
+func humanBytes(n any) string {
+	v := toInt64(n)
+	const unit = 1024
+	if v < unit {
+		return fmt.Sprintf("%dB", v)
+	}
+	div, exp := int64(unit), 0
+	for x := v / unit; x >= unit; x /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f%ciB", float64(v)/float64(div), "KMGTPE"[exp])
+}
+
+// rfc3339 renders a Unix timestamp (seconds, as any integer kind) in RFC3339.
+func rfc3339(sec any) string {
+	return time.Unix(toInt64(sec), 0).UTC().Format(time.RFC3339)
+}
+
+func toInt64(v any) int64 {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	default:
+		return 0
+	}
+}
+
+type templateFormatter struct {
+	t *template.Template
+}
+
+// Format executes the template once per element when v is a slice/array, and
+// once for v itself otherwise - so `--template='{{.Name}}\n'` works the same
+// on a single object and on a list-objects result.
+func (f *templateFormatter) Format(v any, w io.Writer) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			if err := f.t.Execute(w, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return f.t.Execute(w, v)
+}
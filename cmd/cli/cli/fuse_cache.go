@@ -0,0 +1,112 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file implements the LRU bound on bucketFS's page cache (see fuse_mount.go).
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"container/list"
+	"os"
+	"sync"
+)
+
+const (
+	defaultCacheMaxBytes = 1 << 30 // 1GiB
+	defaultCacheMaxCount = 4096
+)
+
+// pageCacheEntry is one cached object's bookkeeping: path/size for the
+// size-bound accounting below, and invalidate to clear the owning
+// bucketFile's pagePath once this entry is reclaimed, so a later Read
+// re-fetches instead of opening a file that's no longer there.
+type pageCacheEntry struct {
+	path       string
+	size       int64
+	invalidate func(path string)
+}
+
+// pageCache is the size/count-bounded LRU tracking every object
+// bucketFile.ensureCached has materialized under one bucketFS.cacheDir.
+// Without it, a long-lived mount keeps every object ever read on disk until
+// the whole mount is torn down; touch reclaims the least-recently-used
+// entries as soon as either bound is exceeded, deleting their on-disk copy
+// and invalidating the owning bucketFile so the next Read just misses the
+// cache and re-GETs, the same as a never-before-read object.
+type pageCache struct {
+	mu         sync.Mutex
+	maxBytes   int64
+	maxCount   int
+	totalBytes int64
+	order      *list.List // front = most recently used
+	index      map[string]*list.Element
+}
+
+func newPageCache(maxBytes int64, maxCount int) *pageCache {
+	return &pageCache{
+		maxBytes: maxBytes,
+		maxCount: maxCount,
+		order:    list.New(),
+		index:    make(map[string]*list.Element),
+	}
+}
+
+// touch records path (size bytes) as the most-recently-used entry, then
+// reclaims from the back of the LRU until both bounds are satisfied. invalidate
+// is stashed for whichever entry eventually gets reclaimed; it's never
+// called for the entry touch itself just inserted or refreshed.
+func (c *pageCache) touch(path string, size int64, invalidate func(path string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[path]; ok {
+		entry := el.Value.(*pageCacheEntry)
+		c.totalBytes += size - entry.size
+		entry.size = size
+		c.order.MoveToFront(el)
+	} else {
+		entry := &pageCacheEntry{path: path, size: size, invalidate: invalidate}
+		c.index[path] = c.order.PushFront(entry)
+		c.totalBytes += size
+	}
+	c.evictLocked()
+}
+
+// remove drops path from the cache's bookkeeping without touching the file
+// on disk or calling invalidate - for a caller (Flush) that's already
+// replacing path's content itself and doesn't need to be told about its own
+// change.
+func (c *pageCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(path)
+}
+
+func (c *pageCache) removeLocked(path string) {
+	el, ok := c.index[path]
+	if !ok {
+		return
+	}
+	entry := el.Value.(*pageCacheEntry)
+	c.order.Remove(el)
+	delete(c.index, path)
+	c.totalBytes -= entry.size
+}
+
+// evictLocked reclaims least-recently-used entries while either bound is
+// exceeded, always leaving at least the most-recently-touched entry in
+// place so a single object larger than maxBytes doesn't evict itself the
+// instant it's added.
+func (c *pageCache) evictLocked() {
+	for c.order.Len() > 1 && (c.totalBytes > c.maxBytes || len(c.index) > c.maxCount) {
+		back := c.order.Back()
+		entry := back.Value.(*pageCacheEntry)
+		c.order.Remove(back)
+		delete(c.index, entry.path)
+		c.totalBytes -= entry.size
+		os.Remove(entry.path)
+		if entry.invalidate != nil {
+			entry.invalidate(entry.path)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file implements the FUSE filesystem backing `ais mount`.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/NVIDIA/aistore/cmn/cos"
+)
+
+type fuseMountOpts struct {
+	bck          cmn.Bck
+	prefix       string
+	mountpath    string
+	readOnly     bool
+	allowOther   bool
+	attrTimeout  time.Duration
+	entryTimeout time.Duration
+}
+
+// runFuseMount mounts opts.bck (optionally scoped to opts.prefix) at
+// opts.mountpath and blocks serving FUSE requests until the filesystem is
+// unmounted (via `ais umount`, fusermount -u, or process exit).
+func runFuseMount(opts fuseMountOpts) error {
+	fuseOpts := []fuse.MountOption{fuse.FSName("ais"), fuse.Subtype("aisfs")}
+	if opts.readOnly {
+		fuseOpts = append(fuseOpts, fuse.ReadOnly())
+	}
+	if opts.allowOther {
+		fuseOpts = append(fuseOpts, fuse.AllowOther())
+	}
+
+	c, err := fuse.Mount(opts.mountpath, fuseOpts...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	cacheDir, err := os.MkdirTemp("", "ais-mount-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(cacheDir)
+
+	root := &bucketFS{opts: opts, cacheDir: cacheDir, cache: newPageCache(defaultCacheMaxBytes, defaultCacheMaxCount)}
+	if err := fs.Serve(c, root); err != nil {
+		return err
+	}
+	<-c.Ready
+	return c.MountError
+}
+
+func fuseUnmount(mountpath string) error {
+	return fuse.Unmount(mountpath)
+}
+
+// bucketFS maps a single bucket (or prefix) onto the FUSE tree: directory
+// listings come from paginated list-objects calls, reads lazily GET and
+// page-cache their object on first access, and writes are buffered locally
+// until `close()` turns them into a single APPEND+finalize PUT. cache bounds
+// how much of cacheDir a long-lived mount is allowed to accumulate,
+// reclaiming least-recently-used objects' page-cache copies once either
+// bound is hit (see fuse_cache.go).
+type bucketFS struct {
+	opts     fuseMountOpts
+	cacheDir string
+	cache    *pageCache
+}
+
+var _ fs.FS = (*bucketFS)(nil)
+
+func (r *bucketFS) Root() (fs.Node, error) {
+	return &bucketDir{fs: r, prefix: r.opts.prefix}, nil
+}
+
+type bucketDir struct {
+	fs     *bucketFS
+	prefix string
+}
+
+var (
+	_ fs.Node               = (*bucketDir)(nil)
+	_ fs.HandleReadDirAller = (*bucketDir)(nil)
+	_ fs.NodeStringLookuper = (*bucketDir)(nil)
+)
+
+func (d *bucketDir) Attr(_ context.Context, a *fuse.Attr) error {
+	a.Mode = os.ModeDir | 0o755
+	a.Valid = d.fs.opts.attrTimeout
+	return nil
+}
+
+func (d *bucketDir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	var (
+		ents []fuse.Dirent
+		msg  = &cmn.SelectMsg{Prefix: d.prefix, PageSize: 1000}
+		seen = make(map[string]struct{})
+	)
+	for {
+		bckList, err := api.ListObjects(apiBP, d.fs.opts.bck, msg, 0)
+		if err != nil {
+			return nil, syscall.EIO
+		}
+		for _, en := range bckList.Entries {
+			rel := strings.TrimPrefix(en.Name, d.prefix)
+			name, isDir := firstPathComponent(rel)
+			if name == "" {
+				continue
+			}
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			typ := fuse.DT_File
+			if isDir {
+				typ = fuse.DT_Dir
+			}
+			ents = append(ents, fuse.Dirent{Name: name, Type: typ})
+		}
+		if bckList.ContinuationToken == "" {
+			break
+		}
+		msg.ContinuationToken = bckList.ContinuationToken
+	}
+	return ents, nil
+}
+
+func (d *bucketDir) Lookup(ctx context.Context, name string) (fs.Node, error) {
+	objName := d.prefix + name
+	if _, err := api.HeadObject(apiBP, d.fs.opts.bck, objName, false); err == nil {
+		return &bucketFile{fs: d.fs, objName: objName}, nil
+	}
+	// not a single object - treat it as a sub-prefix ("directory")
+	return &bucketDir{fs: d.fs, prefix: objName + "/"}, nil
+}
+
+// firstPathComponent returns the first "/"-delimited component of rel and
+// whether rel has more path left after it (i.e., whether it names a
+// sub-"directory" rather than a leaf object).
+func firstPathComponent(rel string) (name string, isDir bool) {
+	if i := strings.IndexByte(rel, '/'); i >= 0 {
+		return rel[:i], true
+	}
+	return rel, false
+}
+
+type bucketFile struct {
+	fs      *bucketFS
+	objName string
+
+	mu       sync.Mutex
+	pagePath string   // lazily-GET'd, LRU-cached copy of the object under fs.cacheDir; cleared by invalidatePage once fs.cache reclaims it
+	wfh      *os.File // non-nil while a write session (open-for-write -> close) is in progress
+}
+
+var (
+	_ fs.Node         = (*bucketFile)(nil)
+	_ fs.HandleReader = (*bucketFile)(nil)
+	_ fs.HandleWriter = (*bucketFile)(nil)
+	_ fs.NodeOpener   = (*bucketFile)(nil)
+)
+
+func (f *bucketFile) Attr(_ context.Context, a *fuse.Attr) error {
+	props, err := api.HeadObject(apiBP, f.fs.opts.bck, f.objName, false)
+	if err != nil {
+		return syscall.ENOENT
+	}
+	a.Mode = 0o644
+	a.Size = uint64(props.Size)
+	a.Valid = f.fs.opts.attrTimeout
+	return nil
+}
+
+func (f *bucketFile) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	resp.Flags |= fuse.OpenKeepCache
+	return f, nil
+}
+
+// Read serves from the local page-cache copy of the object, lazily GET-ing it
+// on first access.
+func (f *bucketFile) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+	path, err := f.ensureCached()
+	if err != nil {
+		return syscall.EIO
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return syscall.EIO
+	}
+	defer fh.Close()
+
+	buf := make([]byte, req.Size)
+	n, err := fh.ReadAt(buf, req.Offset)
+	if err != nil && err != io.EOF {
+		return syscall.EIO
+	}
+	resp.Data = buf[:n]
+	return nil
+}
+
+// ensureCached returns the path of this object's page-cache copy, GET-ing it
+// fresh if there's no cached copy yet - either because this is the first
+// read, or because fs.cache reclaimed the previous one (invalidatePage
+// cleared pagePath) since bounds were exceeded by other objects' traffic.
+func (f *bucketFile) ensureCached() (string, error) {
+	f.mu.Lock()
+	path := f.pagePath
+	f.mu.Unlock()
+	if path != "" {
+		if fi, err := os.Stat(path); err == nil {
+			f.fs.cache.touch(path, fi.Size(), f.invalidatePage)
+			return path, nil
+		}
+		// raced with eviction between the unlock above and the Stat: the
+		// invalidate callback will clear pagePath itself, but fall through
+		// to refetch right away rather than wait for that to land.
+		f.invalidatePage(path)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pagePath != "" {
+		// another reader refetched while we didn't hold the lock.
+		return f.pagePath, nil
+	}
+
+	newPath := filepath.Join(f.fs.cacheDir, cos.SafeFname(f.objName))
+	fh, err := os.Create(newPath)
+	if err != nil {
+		return "", err
+	}
+	defer fh.Close()
+
+	_, err = api.GetObject(apiBP, f.fs.opts.bck, f.objName, api.GetObjectInput{Writer: fh})
+	if err != nil {
+		os.Remove(newPath)
+		return "", err
+	}
+	fi, err := fh.Stat()
+	if err != nil {
+		os.Remove(newPath)
+		return "", err
+	}
+	f.pagePath = newPath
+	f.fs.cache.touch(newPath, fi.Size(), f.invalidatePage)
+	return newPath, nil
+}
+
+// invalidatePage clears pagePath once fs.cache reclaims it, but only if
+// pagePath still names that exact reclaimed copy - a write (Flush) may have
+// already replaced it with a newer one under the same object name.
+func (f *bucketFile) invalidatePage(path string) {
+	f.mu.Lock()
+	if f.pagePath == path {
+		f.pagePath = ""
+	}
+	f.mu.Unlock()
+}
+
+// Write buffers into a scratch file; the buffered content is APPENDed and
+// finalized into a single PUT on Flush, reusing the same
+// APPEND-then-finalize sequence as `putHandler`.
+func (f *bucketFile) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.WriteResponse) error {
+	if f.fs.opts.readOnly {
+		return syscall.EROFS
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.wfh == nil {
+		scratch, err := os.CreateTemp(f.fs.cacheDir, "write-*")
+		if err != nil {
+			return syscall.EIO
+		}
+		f.wfh = scratch
+	}
+	n, err := f.wfh.WriteAt(req.Data, req.Offset)
+	if err != nil {
+		return syscall.EIO
+	}
+	resp.Size = n
+	return nil
+}
+
+func (f *bucketFile) Flush(ctx context.Context, req *fuse.FlushRequest) error {
+	f.mu.Lock()
+	wfh := f.wfh
+	f.wfh = nil
+	f.mu.Unlock()
+	if wfh == nil {
+		return nil
+	}
+	defer os.Remove(wfh.Name())
+	defer wfh.Close()
+
+	if _, err := wfh.Seek(0, io.SeekStart); err != nil {
+		return syscall.EIO
+	}
+	putArgs := api.PutArgs{
+		BaseParams: apiBP,
+		Bck:        f.fs.opts.bck,
+		ObjName:    f.objName,
+		Reader:     cos.NewDeferROC(wfh),
+	}
+	handle, err := api.AppendObject(putArgs)
+	if err != nil {
+		return syscall.EIO
+	}
+	if err := api.FlushObject(api.FlushArgs{PutArgs: putArgs, Handle: handle}); err != nil {
+		return syscall.EIO
+	}
+
+	f.mu.Lock()
+	oldPath := f.pagePath
+	f.pagePath = ""
+	f.mu.Unlock()
+	if oldPath != "" {
+		f.fs.cache.remove(oldPath)
+		os.Remove(oldPath)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file handles CLI commands that mount AIS buckets as a local FUSE filesystem.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	commandMount  = "mount"
+	commandUmount = "umount"
+
+	mountArgument  = "BUCKET[/PREFIX] PATH"
+	umountArgument = "PATH"
+)
+
+var (
+	mountCmdFlags = map[string][]cli.Flag{
+		commandMount: {
+			readOnlyFlag,
+			allowOtherFlag,
+			attrTimeoutFlag,
+			entryTimeoutFlag,
+			daemonFlag,
+		},
+	}
+
+	readOnlyFlag     = cli.BoolFlag{Name: "read-only", Usage: "mount the bucket (or prefix) read-only, rejecting writes"}
+	allowOtherFlag   = cli.BoolFlag{Name: "allow-other", Usage: "allow other users (not just the mount owner) to access the mounted filesystem"}
+	attrTimeoutFlag  = cli.DurationFlag{Name: "attr-timeout", Usage: "duration the kernel caches file attributes for", Value: time.Second}
+	entryTimeoutFlag = cli.DurationFlag{Name: "entry-timeout", Usage: "duration the kernel caches directory entries for", Value: time.Second}
+	daemonFlag       = cli.BoolFlag{Name: "daemon", Usage: "fork into the background and write a PID file so the mount can be managed by systemd"}
+
+	mountCmd = cli.Command{
+		Name:  commandMount,
+		Usage: "mount a bucket (or a prefix within one) as a local, read/write FUSE filesystem",
+		Description: "mounts 'ais://BUCKET[/PREFIX]' at a local PATH so that ordinary tools ('grep', 'tar', ML data loaders)\n" +
+			indent4 + "\tcan operate on it directly, without going through 'ais get'/'ais put' for every object:\n" +
+			indent4 + "\t- directory listings are served via paginated list-objects requests (see '--pagesize' semantics of 'ais ls');\n" +
+			indent4 + "\t- reads lazily GET the object on first access and cache pages on local disk;\n" +
+			indent4 + "\t- writes are buffered locally and, on 'close()', APPENDed and finalized as a single PUT.",
+		ArgsUsage: mountArgument,
+		Flags:     mountCmdFlags[commandMount],
+		Action:    mountHandler,
+	}
+
+	umountCmd = cli.Command{
+		Name:      commandUmount,
+		Usage:     "unmount a bucket previously mounted with 'ais mount'",
+		ArgsUsage: umountArgument,
+		Action:    umountHandler,
+	}
+)
+
+func mountHandler(c *cli.Context) (err error) {
+	if c.NArg() < 2 {
+		return missingArgumentsError(c, "BUCKET[/PREFIX] and a mount PATH")
+	}
+	bck, prefix, err := parseBckObjURI(c, c.Args().Get(0), true /*optional objName*/)
+	if err != nil {
+		return err
+	}
+	if _, err = headBucket(bck, false /* don't add */); err != nil {
+		return err
+	}
+	mountpath, err := filepath.Abs(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	opts := fuseMountOpts{
+		bck:          bck,
+		prefix:       prefix,
+		mountpath:    mountpath,
+		readOnly:     flagIsSet(c, readOnlyFlag),
+		allowOther:   flagIsSet(c, allowOtherFlag),
+		attrTimeout:  c.Duration(attrTimeoutFlag.Name),
+		entryTimeout: c.Duration(entryTimeoutFlag.Name),
+	}
+
+	if !flagIsSet(c, daemonFlag) {
+		return runFuseMount(opts)
+	}
+	return daemonizeMount(c, opts)
+}
+
+func umountHandler(c *cli.Context) (err error) {
+	if c.NArg() == 0 {
+		return missingArgumentsError(c, "mount PATH")
+	}
+	mountpath, err := filepath.Abs(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	if err = fuseUnmount(mountpath); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%q unmounted\n", mountpath)
+	return nil
+}
+
+// daemonizeMount re-execs the current binary with '--daemon' stripped and its
+// stdio detached, writing the child's PID next to the mountpoint so that a
+// process manager (systemd et al.) can track and later 'ais umount' it.
+func daemonizeMount(c *cli.Context, opts fuseMountOpts) error {
+	args := make([]string, 0, len(os.Args))
+	for _, a := range os.Args[1:] {
+		if a != "--"+daemonFlag.Name {
+			args = append(args, a)
+		}
+	}
+	cmd := exec.Command(os.Args[0], args...)
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = devNull, devNull, devNull
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	pidFile := opts.mountpath + ".pid"
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "%q mounted at %q (pid %d, pidfile %q)\n", opts.bck, opts.mountpath, cmd.Process.Pid, pidFile)
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file implements the server/client-side filter DSL shared by `ais ls` and `ais rm`.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/urfave/cli"
+)
+
+var (
+	createdBeforeFlag = cli.StringFlag{Name: "created-before",
+		Usage: "match objects created before this time: Go duration relative to now (e.g. '30d') or an RFC3339 timestamp"}
+	createdAfterFlag = cli.StringFlag{Name: "created-after",
+		Usage: "match objects created after this time: Go duration relative to now (e.g. '30d') or an RFC3339 timestamp"}
+	sizeMinFlag = cli.StringFlag{Name: "size-min", Usage: "match objects at least this big, SI suffix allowed (e.g. '1GiB')"}
+	sizeMaxFlag = cli.StringFlag{Name: "size-max", Usage: "match objects at most this big, SI suffix allowed (e.g. '1GiB')"}
+	versionFlag = cli.StringFlag{Name: "version", Usage: "match objects with this exact version"}
+	customMDFlag = cli.StringSliceFlag{Name: "custom-md",
+		Usage: "match objects whose custom metadata contains key=value (repeatable, all must match)"}
+	includeDeletedFlag = cli.BoolFlag{Name: "include-deleted",
+		Usage: "include deleted (tombstoned) entries of versioned/cloud buckets"}
+	regexNameFlag = regexFlag
+
+	objFilterFlags = []cli.Flag{
+		createdBeforeFlag,
+		createdAfterFlag,
+		sizeMinFlag,
+		sizeMaxFlag,
+		versionFlag,
+		customMDFlag,
+		includeDeletedFlag,
+		regexNameFlag,
+		dryRunFlag,
+	}
+)
+
+// objFilter composes the flags above into a single client-side predicate.
+// Of the bounds, only "include deleted" is pushed into the outgoing
+// SelectMsg as a server-side flag; for the others applyToMsg only requests
+// the entry props that `Match` needs, and `Match` re-checks everything.
+type objFilter struct {
+	createdBefore time.Time
+	createdAfter  time.Time
+	hasCreatedB   bool
+	hasCreatedA   bool
+	sizeMin       int64
+	sizeMax       int64
+	version       string
+	customMD      map[string]string
+	includeDel    bool
+	re            string
+}
+
+func parseObjFilter(c *cli.Context) (f *objFilter, err error) {
+	f = &objFilter{includeDel: flagIsSet(c, includeDeletedFlag)}
+
+	if s := parseStrFlag(c, createdBeforeFlag); s != "" {
+		if f.createdBefore, err = parseTimeBound(s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", qflprn(createdBeforeFlag), err)
+		}
+		f.hasCreatedB = true
+	}
+	if s := parseStrFlag(c, createdAfterFlag); s != "" {
+		if f.createdAfter, err = parseTimeBound(s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", qflprn(createdAfterFlag), err)
+		}
+		f.hasCreatedA = true
+	}
+	if s := parseStrFlag(c, sizeMinFlag); s != "" {
+		if f.sizeMin, err = cos.ParseSize(s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", qflprn(sizeMinFlag), err)
+		}
+	}
+	if s := parseStrFlag(c, sizeMaxFlag); s != "" {
+		if f.sizeMax, err = cos.ParseSize(s); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", qflprn(sizeMaxFlag), err)
+		}
+	}
+	f.version = parseStrFlag(c, versionFlag)
+	f.re = parseStrFlag(c, regexNameFlag)
+
+	if kvs := c.StringSlice(customMDFlag.Name); len(kvs) > 0 {
+		f.customMD = make(map[string]string, len(kvs))
+		for _, kv := range kvs {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid %s %q: expecting key=value", qflprn(customMDFlag), kv)
+			}
+			f.customMD[k] = v
+		}
+	}
+	return f, nil
+}
+
+// parseTimeBound accepts either an RFC3339 timestamp or a Go duration
+// interpreted as "that long ago" (e.g. "30d" -> now minus 30 days). Unlike
+// time.ParseDuration, the 'd' (day) unit is supported since it's the common
+// case for retention-style filters.
+func parseTimeBound(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Now().Add(-time.Duration(days) * 24 * time.Hour), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(-d), nil
+}
+
+// applyToMsg pushes down the parts of the filter the list-objects wire
+// protocol understands; everything else is left to Match as a post-filter.
+func (f *objFilter) applyToMsg(msg *cmn.SelectMsg) {
+	if f.re != "" {
+		msg.Props = cos.AddToProps(msg.Props, "name")
+	}
+	if f.includeDel {
+		msg.Flags |= cmn.SelectDeleted
+	}
+	if f.version != "" {
+		msg.Props = cos.AddToProps(msg.Props, "version")
+	}
+	if f.hasCreatedB || f.hasCreatedA {
+		msg.Props = cos.AddToProps(msg.Props, "atime")
+	}
+	if f.sizeMin > 0 || f.sizeMax > 0 {
+		msg.Props = cos.AddToProps(msg.Props, "size")
+	}
+	if len(f.customMD) > 0 {
+		msg.Props = cos.AddToProps(msg.Props, "custom-md")
+	}
+}
+
+// Match applies every bound client-side; used both as the authoritative
+// filter (backends that don't understand a given bound) and, harmlessly, as
+// a double-check for backends that do.
+func (f *objFilter) Match(en *cmn.BucketEntry) bool {
+	if f.hasCreatedB || f.hasCreatedA {
+		atime, err := time.Parse(time.RFC3339, en.Atime)
+		if err != nil {
+			return false
+		}
+		if f.hasCreatedB && !atime.Before(f.createdBefore) {
+			return false
+		}
+		if f.hasCreatedA && !atime.After(f.createdAfter) {
+			return false
+		}
+	}
+	if f.sizeMin > 0 && en.Size < f.sizeMin {
+		return false
+	}
+	if f.sizeMax > 0 && en.Size > f.sizeMax {
+		return false
+	}
+	if f.version != "" && en.Version != f.version {
+		return false
+	}
+	if !f.includeDel && en.IsStatusDeleted() {
+		return false
+	}
+	if f.re != "" {
+		if matched, err := regexp.MatchString(f.re, en.Name); err != nil || !matched {
+			return false
+		}
+	}
+	for k, v := range f.customMD {
+		if en.CustomMD(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+// hasObjFilterFlags reports whether any of the rich filter flags were passed,
+// so that `removeObjectHandler` can route into the filtered-delete path
+// instead of its plain list/range/rm-rf branches.
+func hasObjFilterFlags(c *cli.Context) bool {
+	for _, fl := range objFilterFlags {
+		if fl.GetName() == dryRunFlag.GetName() {
+			continue // dry-run alone doesn't imply filtering
+		}
+		if flagIsSet(c, fl) {
+			return true
+		}
+	}
+	return false
+}
+
+// rmFiltered deletes (or, with '--dry-run', merely prints) every object
+// under bck/prefix matching the filter flags - e.g. "everything older than
+// 30d bigger than 1GiB".
+func rmFiltered(c *cli.Context, bck cmn.Bck, prefix string) error {
+	f, err := parseObjFilter(c)
+	if err != nil {
+		return err
+	}
+	names, err := filteredNames(bck, prefix, f)
+	if err != nil {
+		return err
+	}
+	if flagIsSet(c, dryRunFlag) {
+		return outputResult(c, names)
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(c.App.Writer, "No objects matched")
+		return nil
+	}
+	if err := api.DeleteList(apiBP, bck, names); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "removed %d object(s) from %s\n", len(names), bck)
+	return nil
+}
+
+// filteredNames runs the full list-objects pagination against bck, applying
+// f at each page, and returns the matched object names - the iterator
+// behind `removeObjectHandler`'s bulk '--created-before'-style deletes
+// (via rmFiltered, its only caller).
+func filteredNames(bck cmn.Bck, prefix string, f *objFilter) ([]string, error) {
+	var (
+		names []string
+		msg   = &cmn.SelectMsg{Prefix: prefix, PageSize: 0 /* backend default */}
+	)
+	f.applyToMsg(msg)
+	for {
+		bckList, err := api.ListObjects(apiBP, bck, msg, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, en := range bckList.Entries {
+			if f.Match(en) {
+				names = append(names, en.Name)
+			}
+		}
+		if bckList.ContinuationToken == "" {
+			break
+		}
+		msg.ContinuationToken = bckList.ContinuationToken
+	}
+	return names, nil
+}
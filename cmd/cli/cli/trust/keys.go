@@ -0,0 +1,316 @@
+// Package trust implements opt-in content signing/verification for AIS
+// objects: Ed25519 detached signatures stored as object custom metadata,
+// keyed against a local, Notary/TUF-style trust store.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package trust
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Custom-MD keys the signature is stashed under, set via the same
+	// code path as `setCustomPropsHandler`.
+	MDSignature  = "x-ais-signature"
+	MDSignerKey  = "x-ais-signer-keyid"
+	MDTimestamp  = "x-ais-signed-at"
+
+	pemBlockPriv         = "AIS TRUST PRIVATE KEY"
+	pemBlockPub          = "AIS TRUST PUBLIC KEY"
+	pemHeaderKeyID       = "Key-Id"
+	pemHeaderEncrypted   = "Encrypted" // "yes" when the private key block is passphrase-wrapped
+	scryptSaltSize       = 16
+	scryptKeyLen         = 32
+	scryptN, scryptR, scryptP = 1 << 15, 8, 1
+)
+
+// Key is a single Ed25519 trust key. Pub is always present; Priv is nil for
+// keys imported/known only by their public half (peers' keys).
+type Key struct {
+	ID   string
+	Pub  ed25519.PublicKey
+	Priv ed25519.PrivateKey
+}
+
+// Fingerprint is a short, human-comparable digest of the public key,
+// displayed by `ais trust list`.
+func (k *Key) Fingerprint() string {
+	sum := sha256.Sum256(k.Pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Dir is the trust store root, following the repo's '~/.config/ais/...'
+// convention for CLI-local state.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".config", "ais", "trust")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// GenerateKey creates a new Ed25519 keypair, PEM-encodes it (optionally
+// passphrase-wrapped via scrypt+secretbox, see wrap/unwrap below) and
+// writes it under Dir() as "<keyid>.pem" (private+public) and
+// "<keyid>.pub" (public only, for distribution/import on another host).
+func GenerateKey(keyID, passphrase string) (*Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k := &Key{ID: keyID, Pub: pub, Priv: priv}
+	if err := saveKey(k, passphrase); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func keyPaths(dir, keyID string) (priv, pub string) {
+	return filepath.Join(dir, keyID+".pem"), filepath.Join(dir, keyID+".pub")
+}
+
+func saveKey(k *Key, passphrase string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	privPath, pubPath := keyPaths(dir, k.ID)
+
+	privBytes, err := x509.MarshalPKCS8PrivateKey(k.Priv)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{pemHeaderKeyID: k.ID}
+	if passphrase != "" {
+		if privBytes, err = wrap(privBytes, passphrase); err != nil {
+			return err
+		}
+		headers[pemHeaderEncrypted] = "yes"
+	}
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: pemBlockPriv, Headers: headers, Bytes: privBytes})
+	if err := os.WriteFile(privPath, privPEM, 0o600); err != nil {
+		return err
+	}
+
+	pubPEM := pem.EncodeToMemory(&pem.Block{
+		Type:    pemBlockPub,
+		Headers: map[string]string{pemHeaderKeyID: k.ID},
+		Bytes:   k.Pub,
+	})
+	return os.WriteFile(pubPath, pubPEM, 0o644)
+}
+
+// ImportKey reads a PEM-encoded public (or private+public) key from src and
+// registers it under Dir() so it can be referenced by keyID thereafter.
+func ImportKey(src, keyID, passphrase string) (*Key, error) {
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: not a PEM file", src)
+	}
+	if keyID == "" {
+		keyID = block.Headers[pemHeaderKeyID]
+	}
+	switch block.Type {
+	case pemBlockPub:
+		return &Key{ID: keyID, Pub: ed25519.PublicKey(block.Bytes)}, saveImportedPub(keyID, block.Bytes)
+	case pemBlockPriv:
+		data := block.Bytes
+		if block.Headers[pemHeaderEncrypted] == "yes" {
+			if data, err = unwrap(data, passphrase); err != nil {
+				return nil, err
+			}
+		}
+		priv, err := x509.ParsePKCS8PrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+		edPriv, ok := priv.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 key", src)
+		}
+		k := &Key{ID: keyID, Priv: edPriv, Pub: edPriv.Public().(ed25519.PublicKey)}
+		return k, saveKey(k, passphrase)
+	default:
+		return nil, fmt.Errorf("%s: unrecognized PEM block %q", src, block.Type)
+	}
+}
+
+func saveImportedPub(keyID string, pub []byte) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	_, pubPath := keyPaths(dir, keyID)
+	pubPEM := pem.EncodeToMemory(&pem.Block{
+		Type:    pemBlockPub,
+		Headers: map[string]string{pemHeaderKeyID: keyID},
+		Bytes:   pub,
+	})
+	return os.WriteFile(pubPath, pubPEM, 0o644)
+}
+
+// ExportKey writes keyID's public key (never the private half) to dst.
+func ExportKey(keyID, dst string) error {
+	k, err := LoadPublic(keyID)
+	if err != nil {
+		return err
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{
+		Type:    pemBlockPub,
+		Headers: map[string]string{pemHeaderKeyID: keyID},
+		Bytes:   k.Pub,
+	})
+	return os.WriteFile(dst, pubPEM, 0o644)
+}
+
+// LoadPrivate loads keyID's private key for signing.
+func LoadPrivate(keyID, passphrase string) (*Key, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	privPath, _ := keyPaths(dir, keyID)
+	raw, err := os.ReadFile(privPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != pemBlockPriv {
+		return nil, fmt.Errorf("%s: not a trust private key", privPath)
+	}
+	data := block.Bytes
+	if block.Headers[pemHeaderEncrypted] == "yes" {
+		if data, err = unwrap(data, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	priv, err := x509.ParsePKCS8PrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	edPriv, ok := priv.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", privPath)
+	}
+	return &Key{ID: keyID, Priv: edPriv, Pub: edPriv.Public().(ed25519.PublicKey)}, nil
+}
+
+// LoadPublic loads keyID's public key for verification.
+func LoadPublic(keyID string) (*Key, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	_, pubPath := keyPaths(dir, keyID)
+	raw, err := os.ReadFile(pubPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != pemBlockPub {
+		return nil, fmt.Errorf("%s: not a trust public key", pubPath)
+	}
+	return &Key{ID: keyID, Pub: ed25519.PublicKey(block.Bytes)}, nil
+}
+
+// List enumerates every known key (by its public half) in Dir().
+func List() ([]*Key, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*Key
+	for _, e := range ents {
+		if filepath.Ext(e.Name()) != ".pub" {
+			continue
+		}
+		keyID := e.Name()[:len(e.Name())-len(".pub")]
+		k, err := LoadPublic(keyID)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// wrap/unwrap passphrase-protect a private-key blob with
+// scrypt(passphrase, salt) as the key to NaCl secretbox (XSalsa20-Poly1305
+// AEAD). An earlier version of this XORed the scrypt output directly
+// against the PKCS8 DER bytes as a one-time keystream, which leaks that
+// keystream from a single ciphertext: Ed25519 PKCS8 keys start with a fixed
+// DER prefix, so `ciphertext[:n] XOR knownPrefix` recovers the first n
+// keystream bytes outright. secretbox authenticates the blob (Open fails
+// closed on any tampering or wrong passphrase) instead of just obscuring it.
+func wrap(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, scryptSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	keyBytes, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], keyBytes)
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	sealed := secretbox.Seal(nil, data, &nonce, &key)
+
+	out := make([]byte, 0, scryptSaltSize+len(nonce)+len(sealed))
+	out = append(out, salt...)
+	out = append(out, nonce[:]...)
+	out = append(out, sealed...)
+	return out, nil
+}
+
+func unwrap(blob []byte, passphrase string) ([]byte, error) {
+	if len(blob) < scryptSaltSize+24 {
+		return nil, fmt.Errorf("corrupt encrypted key")
+	}
+	salt, rest := blob[:scryptSaltSize], blob[scryptSaltSize:]
+	var nonce [24]byte
+	copy(nonce[:], rest[:24])
+	sealed := rest[24:]
+
+	keyBytes, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], keyBytes)
+
+	data, ok := secretbox.Open(nil, sealed, &nonce, &key)
+	if !ok {
+		return nil, fmt.Errorf("wrong passphrase or corrupt encrypted key")
+	}
+	return data, nil
+}
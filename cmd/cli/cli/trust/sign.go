@@ -0,0 +1,54 @@
+// Package trust - see keys.go
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package trust
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Meta is the subset of object identity/content state a signature covers:
+// {bucket, object, size, checksum, timestamp}, as specified for the PUT/GET
+// signing hooks.
+type Meta struct {
+	Bucket    string
+	Object    string
+	Size      int64
+	Checksum  string
+	Timestamp int64 // unix seconds
+}
+
+func (m *Meta) canonical() []byte {
+	return []byte(strings.Join([]string{
+		m.Bucket, m.Object,
+		strconv.FormatInt(m.Size, 10),
+		m.Checksum,
+		strconv.FormatInt(m.Timestamp, 10),
+	}, "\x00"))
+}
+
+// Sign produces a base64 detached signature over m using k's private key.
+func Sign(k *Key, m *Meta) (string, error) {
+	if k.Priv == nil {
+		return "", fmt.Errorf("key %q has no private half loaded", k.ID)
+	}
+	sig := ed25519.Sign(k.Priv, m.canonical())
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks sigB64 against m using k's public key.
+func Verify(k *Key, m *Meta, sigB64 string) error {
+	sig, err := base64.StdEncoding.DecodeString(sigB64)
+	if err != nil {
+		return fmt.Errorf("malformed signature: %v", err)
+	}
+	if !ed25519.Verify(k.Pub, m.canonical(), sig) {
+		return fmt.Errorf("signature verification failed for %s/%s (keyid %s)", m.Bucket, m.Object, k.ID)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+// Package cli provides easy-to-use commands to manage, monitor, and utilize AIS clusters.
+// This file handles CLI commands for the opt-in content-trust subsystem (see cli/trust).
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmd/cli/cli/trust"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/urfave/cli"
+)
+
+const (
+	commandTrust = "trust"
+
+	commandTrustKey      = "key"
+	commandTrustGenerate = "generate"
+	commandTrustImport   = "import"
+	commandTrustExport   = "export"
+	commandTrustSign     = "sign"
+	commandTrustVerify   = "verify"
+	commandTrustList     = "list"
+
+	envTrustRequired = "AIS_TRUST_REQUIRED"
+)
+
+var (
+	signFlag       = cli.BoolFlag{Name: "sign", Usage: "sign the object's content on PUT (see 'ais trust')"}
+	verifyFlag     = cli.BoolFlag{Name: "verify", Usage: "verify the object's signature on GET, failing closed if missing/invalid"}
+	keyIDFlag      = cli.StringFlag{Name: "keyid", Usage: "trust key ID"}
+	passphraseFlag = cli.StringFlag{Name: "passphrase", Usage: "passphrase protecting the private key (optional)"}
+
+	trustCmd = cli.Command{
+		Name:  commandTrust,
+		Usage: "sign objects on PUT and verify them on GET using a local Ed25519 trust store",
+		Subcommands: []cli.Command{
+			{
+				Name:  commandTrustKey,
+				Usage: "manage trust keys",
+				Subcommands: []cli.Command{
+					{
+						Name:      commandTrustGenerate,
+						Usage:     "generate a new Ed25519 trust key",
+						ArgsUsage: "KEY_ID",
+						Flags:     []cli.Flag{passphraseFlag},
+						Action:    trustKeyGenerateHandler,
+					},
+					{
+						Name:      commandTrustImport,
+						Usage:     "import a PEM-encoded trust key",
+						ArgsUsage: "PEM_FILE",
+						Flags:     []cli.Flag{keyIDFlag, passphraseFlag},
+						Action:    trustKeyImportHandler,
+					},
+					{
+						Name:      commandTrustExport,
+						Usage:     "export a trust key's public half to a PEM file",
+						ArgsUsage: "KEY_ID PEM_FILE",
+						Action:    trustKeyExportHandler,
+					},
+				},
+			},
+			{
+				Name:      commandTrustSign,
+				Usage:     "sign an already-PUT object, attaching the signature as custom metadata",
+				ArgsUsage: objectArgument,
+				Flags:     []cli.Flag{keyIDFlag, passphraseFlag},
+				Action:    trustSignHandler,
+			},
+			{
+				Name:      commandTrustVerify,
+				Usage:     "verify an object's signature against the local trust store",
+				ArgsUsage: objectArgument,
+				Action:    trustVerifyHandler,
+			},
+			{
+				Name:   commandTrustList,
+				Usage:  "list known trust keys (keyid, algorithm, fingerprint)",
+				Flags:  []cli.Flag{formatFlag, formatTemplateFlag},
+				Action: trustListHandler,
+			},
+		},
+	}
+)
+
+func trustKeyGenerateHandler(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return missingArgumentsError(c, "KEY_ID")
+	}
+	k, err := trust.GenerateKey(c.Args().Get(0), parseStrFlag(c, passphraseFlag))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "generated key %q (fingerprint %s)\n", k.ID, k.Fingerprint())
+	return nil
+}
+
+func trustKeyImportHandler(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return missingArgumentsError(c, "PEM_FILE")
+	}
+	k, err := trust.ImportKey(c.Args().Get(0), parseStrFlag(c, keyIDFlag), parseStrFlag(c, passphraseFlag))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "imported key %q (fingerprint %s)\n", k.ID, k.Fingerprint())
+	return nil
+}
+
+func trustKeyExportHandler(c *cli.Context) error {
+	if c.NArg() < 2 {
+		return missingArgumentsError(c, "KEY_ID PEM_FILE")
+	}
+	if err := trust.ExportKey(c.Args().Get(0), c.Args().Get(1)); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "exported %q to %q\n", c.Args().Get(0), c.Args().Get(1))
+	return nil
+}
+
+func trustListHandler(c *cli.Context) error {
+	keys, err := trust.List()
+	if err != nil {
+		return err
+	}
+	rows := make([]trustKeyRow, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, trustKeyRow{KeyID: k.ID, Algorithm: "ed25519", Fingerprint: k.Fingerprint()})
+	}
+	return outputResult(c, rows)
+}
+
+type trustKeyRow struct {
+	KeyID       string `col:"KEYID"`
+	Algorithm   string `col:"ALGORITHM"`
+	Fingerprint string `col:"FINGERPRINT"`
+}
+
+func trustSignHandler(c *cli.Context) (err error) {
+	if c.NArg() == 0 {
+		return missingArgumentsError(c, c.Command.ArgsUsage)
+	}
+	bck, objName, err := parseBckObjURI(c, c.Args().Get(0), false)
+	if err != nil {
+		return err
+	}
+	keyID := parseStrFlag(c, keyIDFlag)
+	if keyID == "" {
+		return incorrectUsageMsg(c, "%s is required", qflprn(keyIDFlag))
+	}
+	return signObject(c, bck, objName, keyID, parseStrFlag(c, passphraseFlag))
+}
+
+// signObject computes {bucket, object, size, checksum, timestamp} for an
+// already-PUT object, signs it with keyID, and stores the result as custom
+// metadata via the same code path as `setCustomPropsHandler`.
+func signObject(c *cli.Context, bck cmn.Bck, objName, keyID, passphrase string) error {
+	props, err := api.HeadObject(apiBP, bck, objName, false)
+	if err != nil {
+		return err
+	}
+	k, err := trust.LoadPrivate(keyID, passphrase)
+	if err != nil {
+		return err
+	}
+	meta := &trust.Meta{
+		Bucket: bck.Name, Object: objName,
+		Size: props.Size, Checksum: props.Checksum().Value(),
+		Timestamp: time.Now().Unix(),
+	}
+	sig, err := trust.Sign(k, meta)
+	if err != nil {
+		return err
+	}
+	if err := api.SetObjectCustomProps(apiBP, bck, objName, map[string]string{
+		trust.MDSignature: sig,
+		trust.MDSignerKey: keyID,
+		trust.MDTimestamp: strconv.FormatInt(meta.Timestamp, 10),
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "signed %s/%s with key %q\n", bck, objName, keyID)
+	return nil
+}
+
+func trustVerifyHandler(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return missingArgumentsError(c, c.Command.ArgsUsage)
+	}
+	bck, objName, err := parseBckObjURI(c, c.Args().Get(0), false)
+	if err != nil {
+		return err
+	}
+	tm, err := loadTrustMeta(bck, objName)
+	if err != nil {
+		return err
+	}
+	if err := verifyObject(bck, objName, tm, tm.checksum); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "OK: %s/%s\n", bck, objName)
+	return nil
+}
+
+// trustMeta is what a single metadata-only HeadObject gives verifyObject:
+// the signature plus everything else that went into signObject's Meta,
+// fetched once up front so a streaming caller (getHandler/catHandler, via
+// loadTrustMeta) can pick a hasher and compute its own content digest
+// before deciding whether the object verifies - rather than verifyObject
+// re-deriving "the current checksum" from a second HeadObject, which only
+// proves the cluster agrees with itself, not that bytes landing on dst are
+// the ones that were signed.
+type trustMeta struct {
+	size      int64
+	checksum  string // cluster-reported digest, cksumType-typed; GET callers discard this in favor of their own
+	cksumType string
+	sig       string
+	keyID     string
+	timestamp int64
+}
+
+// loadTrustMeta fetches objName's signature, signer key ID, timestamp,
+// cluster-reported checksum and checksum type - everything verifyObject
+// needs except the content digest itself, which the caller supplies
+// separately (either this same cluster-reported value, for
+// trustVerifyHandler's no-download case, or one hashed live off a GET's
+// bytes).
+func loadTrustMeta(bck cmn.Bck, objName string) (*trustMeta, error) {
+	props, err := api.HeadObject(apiBP, bck, objName, false)
+	if err != nil {
+		return nil, err
+	}
+	sig, ok := props.CustomMD()[trust.MDSignature]
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: not signed (missing %s)", bck, objName, trust.MDSignature)
+	}
+	keyID, ok := props.CustomMD()[trust.MDSignerKey]
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: missing %s", bck, objName, trust.MDSignerKey)
+	}
+	ts, err := strconv.ParseInt(props.CustomMD()[trust.MDTimestamp], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: missing or invalid %s", bck, objName, trust.MDTimestamp)
+	}
+	return &trustMeta{
+		size:      props.Size,
+		checksum:  props.Checksum().Value(),
+		cksumType: props.Checksum().Type(),
+		sig:       sig,
+		keyID:     keyID,
+		timestamp: ts,
+	}, nil
+}
+
+// verifyObject checks contentDigest against tm's signature, failing closed
+// (non-nil error) on anything missing or invalid. trustVerifyHandler passes
+// tm.checksum itself (verifying the object as the cluster currently has
+// it, no content fetch involved); getHandler/catHandler pass a digest they
+// computed themselves while streaming the object to dst, so a GET response
+// that diverges from what's actually stored can't pass verification just
+// because a second HeadObject still reports the original checksum.
+func verifyObject(bck cmn.Bck, objName string, tm *trustMeta, contentDigest string) error {
+	k, err := trust.LoadPublic(tm.keyID)
+	if err != nil {
+		return fmt.Errorf("%s/%s: signer key %q unknown to local trust store: %v", bck, objName, tm.keyID, err)
+	}
+	meta := &trust.Meta{
+		Bucket: bck.Name, Object: objName,
+		Size: tm.size, Checksum: contentDigest,
+		Timestamp: tm.timestamp,
+	}
+	return trust.Verify(k, meta, tm.sig)
+}
+
+// verifyRequired reports whether GET-time verification should be enforced
+// even without an explicit '--verify' flag.
+func verifyRequired(c *cli.Context) bool {
+	return flagIsSet(c, verifyFlag) || os.Getenv(envTrustRequired) == "1"
+}
@@ -0,0 +1,112 @@
+// Package k8s wraps the client-go calls the CLI's `ais k8s` subcommands need,
+// replacing the `kubectl exec`-based implementation those commands used to
+// shell out to.
+/*
+ * Copyright (c) 2021, NVIDIA CORPORATION. All rights reserved.
+ */
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Namespace is the namespace every AIS daemon pod/service is deployed into;
+// the same "ais" literal the old `kubectl ... -n ais` command lines hardcoded.
+const Namespace = "ais"
+
+// DaemonIDLabel selects a single daemon's pod the way `ais k8s cluster
+// <daemon-id>` used to via `kubectl ... --selector=ais-daemon-id=<id>`.
+const DaemonIDLabel = "ais-daemon-id"
+
+// NewClientset builds a kubernetes.Interface the same way kubectl itself
+// resolves its target: in-cluster config first (the CLI running as a pod
+// inside the same cluster it's inspecting), falling back to the operator's
+// local kubeconfig (~/.kube/config, or $KUBECONFIG) otherwise.
+func NewClientset() (kubernetes.Interface, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		cfg, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(),
+			&clientcmd.ConfigOverrides{},
+		).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("k8s: failed to load in-cluster or local kubeconfig: %w", err)
+		}
+	}
+	return kubernetes.NewForConfig(cfg)
+}
+
+// ListPods lists every pod in Namespace, optionally narrowed by selector
+// (e.g. "ais-daemon-id=<id>") - the structured replacement for `kubectl get
+// pods [-o=wide] [--selector=...]`.
+func ListPods(ctx context.Context, cs kubernetes.Interface, selector string) ([]corev1.Pod, error) {
+	list, err := cs.CoreV1().Pods(Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// ListServices lists every service in Namespace - the structured
+// replacement for `kubectl get svc -n ais`.
+func ListServices(ctx context.Context, cs kubernetes.Interface) ([]corev1.Service, error) {
+	list, err := cs.CoreV1().Services(Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// ListEvents lists every event in Namespace, newest first - backs `ais k8s
+// events`, a subcommand `kubectl exec`-based tooling had no equivalent for
+// without a second shell-out to `kubectl get events`.
+func ListEvents(ctx context.Context, cs kubernetes.Interface) ([]corev1.Event, error) {
+	list, err := cs.CoreV1().Events(Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	events := list.Items
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// PodLogs copies podName's log output into w as it arrives - backs `ais k8s
+// logs <daemon-id>`, which previously had no equivalent at all since
+// `kubectl exec` doesn't read a pod's logs. With follow=true the underlying
+// stream never closes on its own for a running pod, so this writes each
+// chunk to w immediately rather than buffering the whole thing in memory;
+// the caller cancels ctx (e.g. on Ctrl-C) to stop following.
+func PodLogs(ctx context.Context, cs kubernetes.Interface, podName string, follow bool, w io.Writer) error {
+	req := cs.CoreV1().Pods(Namespace).GetLogs(podName, &corev1.PodLogOptions{Follow: follow})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(w, stream)
+	return err
+}
+
+// PodForDaemonID finds the single pod labeled DaemonIDLabel=daemonID, the
+// structured lookup `k8sShowSingleDaemon` used a `--selector=` kubectl flag
+// for and `PodLogs`'s caller now uses to resolve a daemon ID to a pod name.
+func PodForDaemonID(ctx context.Context, cs kubernetes.Interface, daemonID string) (*corev1.Pod, error) {
+	pods, err := ListPods(ctx, cs, DaemonIDLabel+"="+daemonID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("k8s: no pod found for daemon ID %q (label %s)", daemonID, DaemonIDLabel)
+	}
+	return &pods[0], nil
+}
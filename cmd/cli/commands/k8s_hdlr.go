@@ -6,19 +6,31 @@
 package commands
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
+	"text/tabwriter"
 
 	"github.com/NVIDIA/aistore/api"
+	"github.com/NVIDIA/aistore/cmd/cli/commands/k8s"
 	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	subcmdK8sLogs   = "logs"
+	subcmdK8sEvents = "events"
 )
 
 var (
 	k8sCmdsFlags = map[string][]cli.Flag{
 		subcmdK8sSvc:     {},
 		subcmdK8sCluster: {},
+		subcmdK8sLogs:    {followLogsFlag},
+		subcmdK8sEvents:  {},
 	}
 
+	followLogsFlag = cli.BoolFlag{Name: "follow", Usage: "stream new log lines as they're written, like 'kubectl logs -f'"}
+
 	k8sCmd = cli.Command{
 		Name:  subcmdK8s,
 		Usage: "show kubernetes pods and services",
@@ -42,22 +54,45 @@ var (
 					suggestDaemon(completeAllDaemons)
 				},
 			},
+			{
+				Name:      subcmdK8sLogs,
+				Usage:     "show a daemon's pod log",
+				Flags:     k8sCmdsFlags[subcmdK8sLogs],
+				ArgsUsage: daemonIDArgument,
+				Action:    k8sLogsHandler,
+				BashComplete: func(c *cli.Context) {
+					if c.NArg() != 0 {
+						return
+					}
+					suggestDaemon(completeAllDaemons)
+				},
+			},
+			{
+				Name:   subcmdK8sEvents,
+				Usage:  "show kubernetes events in the AIS namespace",
+				Flags:  k8sCmdsFlags[subcmdK8sEvents],
+				Action: k8sEventsHandler,
+			},
 		},
 	}
-
-	// kubectl command lines
-	cmdPodList  = []string{"get", "pods"}
-	cmdSvcList  = []string{"get", "svc", "-n", "ais"}
-	cmdNodeInfo = []string{"get", "pods", "-n", "ais", "-o=wide"}
 )
 
-func k8sShowSvcHandler(c *cli.Context) (err error) {
-	output, err := exec.Command("kubectl", cmdSvcList...).CombinedOutput()
+func k8sShowSvcHandler(c *cli.Context) error {
+	cs, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+	svcs, err := k8s.ListServices(context.Background(), cs)
 	if err != nil {
 		return err
 	}
-	fmt.Fprint(c.App.Writer, string(output))
-	return nil
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tCLUSTER-IP\tPORTS")
+	for i := range svcs {
+		svc := &svcs[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", svc.Name, svc.Spec.Type, svc.Spec.ClusterIP, formatPorts(svc))
+	}
+	return w.Flush()
 }
 
 func k8sShowClusterHandler(c *cli.Context) error {
@@ -67,16 +102,19 @@ func k8sShowClusterHandler(c *cli.Context) error {
 	return k8sShowSingleDaemon(c)
 }
 
-func k8sShowEntireCluster(c *cli.Context) (err error) {
-	output, err := exec.Command(subcmdK8s, cmdPodList...).CombinedOutput()
+func k8sShowEntireCluster(c *cli.Context) error {
+	cs, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+	pods, err := k8s.ListPods(context.Background(), cs, "")
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Fprint(c.App.Writer, string(output))
-	return err
+	return printPods(c, pods)
 }
 
-func k8sShowSingleDaemon(c *cli.Context) (err error) {
+func k8sShowSingleDaemon(c *cli.Context) error {
 	smap, err := api.GetClusterMap(defaultAPIParams)
 	if err != nil {
 		return err
@@ -85,13 +123,77 @@ func k8sShowSingleDaemon(c *cli.Context) (err error) {
 	if node := smap.GetNode(daemonID); node == nil {
 		return fmt.Errorf("%s does not exist in the cluster (see 'ais show cluster')", daemonID)
 	}
-	cmdLine := make([]string, 0, len(cmdNodeInfo)+1)
-	cmdLine = append(cmdLine, cmdNodeInfo...)
-	cmdLine = append(cmdLine, "--selector=ais-daemon-id="+daemonID)
-	output, err := exec.Command(subcmdK8s, cmdLine...).CombinedOutput()
+
+	cs, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+	pods, err := k8s.ListPods(context.Background(), cs, k8s.DaemonIDLabel+"="+daemonID)
 	if err != nil {
 		return err
 	}
-	_, err = fmt.Fprint(c.App.Writer, string(output))
-	return err
+	return printPods(c, pods)
+}
+
+// k8sLogsHandler is new: `kubectl exec` had no way to read a pod's logs, so
+// an operator previously had to drop to a raw `kubectl logs` outside the CLI
+// entirely.
+func k8sLogsHandler(c *cli.Context) error {
+	daemonID := c.Args().First()
+	if daemonID == "" {
+		return fmt.Errorf("missing %s argument", daemonIDArgument)
+	}
+	cs, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pod, err := k8s.PodForDaemonID(ctx, cs, daemonID)
+	if err != nil {
+		return err
+	}
+	return k8s.PodLogs(ctx, cs, pod.Name, flagIsSet(c, followLogsFlag), c.App.Writer)
+}
+
+// k8sEventsHandler is also new, for the same reason k8sLogsHandler is: there
+// was no programmatic equivalent to reach for before `ais k8s cluster` could
+// do more than proxy a `kubectl` text dump.
+func k8sEventsHandler(c *cli.Context) error {
+	cs, err := k8s.NewClientset()
+	if err != nil {
+		return err
+	}
+	events, err := k8s.ListEvents(context.Background(), cs)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, ev := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s/%s\t%s\n",
+			ev.LastTimestamp.Time.Format("15:04:05"), ev.Type, ev.Reason, ev.InvolvedObject.Kind, ev.InvolvedObject.Name, ev.Message)
+	}
+	return w.Flush()
+}
+
+func printPods(c *cli.Context, pods []corev1.Pod) error {
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tNODE\tPOD-IP\tDAEMON-ID")
+	for i := range pods {
+		pod := &pods[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			pod.Name, pod.Status.Phase, pod.Spec.NodeName, pod.Status.PodIP, pod.Labels[k8s.DaemonIDLabel])
+	}
+	return w.Flush()
+}
+
+func formatPorts(svc *corev1.Service) string {
+	s := ""
+	for i, p := range svc.Spec.Ports {
+		if i > 0 {
+			s += ","
+		}
+		s += fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+	}
+	return s
 }
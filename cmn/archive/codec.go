@@ -0,0 +1,106 @@
+// Package archive
+/*
+ * Copyright (c) 2018-2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package archive
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/NVIDIA/aistore/memsys"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// PerMemberCodec compresses an individual archive member _before_ it is handed
+// to the container format (tar/zip/msgpack); independent of - and composable
+// with - the container-level codec (e.g., tar.zst, see zstd.go).
+type PerMemberCodec string
+
+const (
+	CodecNone PerMemberCodec = ""
+	CodecGzip PerMemberCodec = "gzip"
+	CodecZstd PerMemberCodec = "zstd"
+	CodecLZ4  PerMemberCodec = "lz4"
+)
+
+func ValidateMemberCodec(c PerMemberCodec) error {
+	switch c {
+	case CodecNone, CodecGzip, CodecZstd, CodecLZ4:
+		return nil
+	default:
+		return fmt.Errorf("invalid per-member archive codec %q", c)
+	}
+}
+
+// EncodeMember compresses `r` (the object's content) per `codec`, spooling the
+// result into an SGL (memsys scatter-gather list: in-memory up to a threshold,
+// then disk-backed) so that the exact compressed size is known before the
+// container format writes its per-member header (tar/zip need size upfront).
+func EncodeMember(codec PerMemberCodec, r io.Reader) (sgl *memsys.SGL, err error) {
+	sgl = memsys.PageMM().NewSGL(0)
+	var w io.WriteCloser
+	switch codec {
+	case CodecNone:
+		_, err = io.Copy(sgl, r)
+		return sgl, err
+	case CodecGzip:
+		w = gzip.NewWriter(sgl)
+	case CodecZstd:
+		w, err = zstd.NewWriter(sgl)
+		if err != nil {
+			sgl.Free()
+			return nil, err
+		}
+	case CodecLZ4:
+		w = lz4.NewWriter(sgl)
+	default:
+		sgl.Free()
+		return nil, fmt.Errorf("invalid per-member archive codec %q", codec)
+	}
+	if _, err = io.Copy(w, r); err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		sgl.Free()
+		return nil, err
+	}
+	return sgl, nil
+}
+
+// NameWithCodec appends the codec's conventional suffix to the in-archive member
+// name so that downstream readers (and humans) know how to decompress it.
+func NameWithCodec(name string, codec PerMemberCodec) string {
+	switch codec {
+	case CodecNone:
+		return name
+	case CodecGzip:
+		return name + ".gz"
+	case CodecZstd:
+		return name + ".zst"
+	case CodecLZ4:
+		return name + ".lz4"
+	default:
+		return name
+	}
+}
+
+// interface guard: SGL must be usable as a plain cos.ReadOpenCloser-like reader+writer
+var _ io.ReadWriter = (*memsys.SGL)(nil)
+
+type sizeOverrideOAH struct {
+	cos.OAH
+	size int64
+}
+
+func (s *sizeOverrideOAH) SizeBytes() int64 { return s.size }
+
+// WithSize wraps `oah` to report `size` instead of its own - used when the
+// bytes actually being written (e.g., per-member-compressed) differ in length
+// from the original object, so that tar/zip headers carry the correct size.
+func WithSize(oah cos.OAH, size int64) cos.OAH {
+	return &sizeOverrideOAH{OAH: oah, size: size}
+}
@@ -0,0 +1,354 @@
+// Package archive
+/*
+ * Copyright (c) 2018-2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/NVIDIA/aistore/cmn/cos"
+)
+
+// ExtMsgpack is a length-prefixed stream of `{name, attrs, data}` records,
+// followed by a trailing index block (always written at Fini, and required by
+// readIndex and OpenMsgpackForAppend) that maps member name => {offset, size}
+// for random-access GET.
+const ExtMsgpack = ".msgpack"
+
+const (
+	mpMagic      = uint32(0xa15da7a1) // "aisdata" - ad hoc but stable across versions
+	mpFooterSize = 4 /*magic*/ + 8 /*index offset*/ + 4 /*index count*/
+)
+
+type (
+	// one on-disk record: fixmap-like framing, hand-rolled (no msgpack dependency)
+	//   uint32(nameLen) name
+	//   uint32(attrsLen) attrs (itself: size int64, atime int64, cksum-type string, cksum-value string)
+	//   uint64(dataLen) data
+	msgpackWriter struct {
+		baseW
+		off   int64            // current write offset (== body length so far)
+		index map[string]mpEntry // member name -> {offset, size}, built incrementally
+	}
+	mpEntry struct {
+		Offset int64
+		Size   int64
+	}
+)
+
+// interface guard
+var _ Writer = (*msgpackWriter)(nil)
+
+func (mw *msgpackWriter) init(w io.Writer, cksum *cos.CksumHashSize, serialize bool) {
+	mw.baseW.init(w, cksum, serialize)
+	mw.index = make(map[string]mpEntry)
+	// APPEND case: `w` is already positioned past the existing body (see `OpenMsgpackForAppend`);
+	// track that as our starting offset so freshly-written entries get correct (absolute) offsets.
+	if seeker, ok := w.(io.Seeker); ok {
+		if off, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			mw.off = off
+		}
+	}
+}
+
+func (mw *msgpackWriter) Fini() {
+	mw.writeIndex()
+	mw.slab.Free(mw.buf)
+}
+
+func (mw *msgpackWriter) Write(fullname string, oah cos.OAH, reader io.Reader) error {
+	mw.lck.Lock()
+	defer mw.lck.Unlock()
+
+	size := oah.SizeBytes()
+	n, err := mw.writeRecord(fullname, oah, reader, size)
+	if err == nil {
+		mw.index[fullname] = mpEntry{Offset: mw.off, Size: n}
+		mw.off += n
+	}
+	return err
+}
+
+// writes one record and returns the number of bytes written for `data` plus framing;
+// `reader` is copied straight through to `mw.wmul` - no whole-member buffering.
+func (mw *msgpackWriter) writeRecord(fullname string, oah cos.OAH, reader io.Reader, size int64) (n int64, err error) {
+	var hdr [8]byte
+
+	binary.BigEndian.PutUint32(hdr[:4], uint32(len(fullname)))
+	if _, err = mw.wmul.Write(hdr[:4]); err != nil {
+		return
+	}
+	if _, err = io.WriteString(mw.wmul, fullname); err != nil {
+		return
+	}
+
+	attrs := encodeAttrs(oah)
+	binary.BigEndian.PutUint32(hdr[:4], uint32(len(attrs)))
+	if _, err = mw.wmul.Write(hdr[:4]); err != nil {
+		return
+	}
+	if _, err = mw.wmul.Write(attrs); err != nil {
+		return
+	}
+
+	binary.BigEndian.PutUint64(hdr[:8], uint64(size))
+	if _, err = mw.wmul.Write(hdr[:8]); err != nil {
+		return
+	}
+	var copied int64
+	copied, err = io.CopyBuffer(mw.wmul, reader, mw.buf)
+	n = int64(4+len(fullname)+4+len(attrs)+8) + copied
+	return
+}
+
+// `Copy` splices an existing msgpack archive (its body, sans trailing index) into
+// this one - used on the APPEND-with-new-version path (mirrors tarWriter/zipWriter).
+func (mw *msgpackWriter) Copy(src io.Reader, _ ...int64) error {
+	n, err := io.CopyBuffer(mw.wmul, src, mw.buf)
+	if err != nil {
+		return err
+	}
+	mw.off += n
+	return nil
+}
+
+func (mw *msgpackWriter) writeIndex() {
+	var (
+		hdr    [8]byte
+		footer [mpFooterSize]byte
+		idxOff = mw.off
+		count  uint32
+	)
+	for name, e := range mw.index {
+		binary.BigEndian.PutUint32(hdr[:4], uint32(len(name)))
+		mw.wmul.Write(hdr[:4])
+		io.WriteString(mw.wmul, name)
+		binary.BigEndian.PutUint64(hdr[:8], uint64(e.Offset))
+		mw.wmul.Write(hdr[:8])
+		binary.BigEndian.PutUint64(hdr[:8], uint64(e.Size))
+		mw.wmul.Write(hdr[:8])
+		count++
+	}
+	binary.BigEndian.PutUint32(footer[:4], mpMagic)
+	binary.BigEndian.PutUint64(footer[4:12], uint64(idxOff))
+	binary.BigEndian.PutUint32(footer[12:16], count)
+	mw.wmul.Write(footer[:])
+}
+
+func encodeAttrs(oah cos.OAH) []byte {
+	var (
+		cksumType, cksumVal string
+		buf                 [8]byte
+	)
+	if cksum := oah.Cksum(); cksum != nil {
+		cksumType, cksumVal = cksum.Get()
+	}
+	out := make([]byte, 0, 16+len(cksumType)+len(cksumVal))
+	binary.BigEndian.PutUint64(buf[:], uint64(oah.SizeBytes()))
+	out = append(out, buf[:]...)
+	binary.BigEndian.PutUint64(buf[:], uint64(oah.AtimeUnix()))
+	out = append(out, buf[:]...)
+	out = append(out, byte(len(cksumType)))
+	out = append(out, cksumType...)
+	out = append(out, byte(len(cksumVal)))
+	out = append(out, cksumVal...)
+	return out
+}
+
+// OpenMsgpackForAppend seeks `fh` (already renamed to the work FQN) to the start
+// of the trailing index block - i.e., the end of the member body - so that a
+// caller can resume writing new records right after the last existing one,
+// mirroring `OpenTarSeekEnd`. The stale index is discarded; `Fini` rewrites it.
+func OpenMsgpackForAppend(fqn string) (fh *os.File, bodyEnd int64, err error) {
+	fh, err = os.OpenFile(fqn, os.O_RDWR, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	st, err := fh.Stat()
+	if err != nil {
+		cos.Close(fh)
+		return nil, 0, err
+	}
+	if st.Size() < mpFooterSize {
+		cos.Close(fh)
+		return nil, 0, errors.New("msgpack archive: too short, missing footer")
+	}
+	footer := make([]byte, mpFooterSize)
+	if _, err = fh.ReadAt(footer, st.Size()-mpFooterSize); err != nil {
+		cos.Close(fh)
+		return nil, 0, err
+	}
+	if binary.BigEndian.Uint32(footer[:4]) != mpMagic {
+		cos.Close(fh)
+		return nil, 0, errors.New("msgpack archive: bad footer magic")
+	}
+	bodyEnd = int64(binary.BigEndian.Uint64(footer[4:12]))
+	if _, err = fh.Seek(bodyEnd, io.SeekStart); err != nil {
+		cos.Close(fh)
+		return nil, 0, err
+	}
+	if err = fh.Truncate(bodyEnd); err != nil {
+		cos.Close(fh)
+		return nil, 0, err
+	}
+	return fh, bodyEnd, nil
+}
+
+// MsgpackAttrs is encodeAttrs' read-side counterpart: the {size, atime,
+// cksum} triple decoded back out of one record's attrs block.
+type MsgpackAttrs struct {
+	Size      int64
+	AtimeUnix int64
+	CksumType string
+	CksumVal  string
+}
+
+func decodeAttrs(b []byte) (a MsgpackAttrs, err error) {
+	if len(b) < 8+8+1 {
+		return a, errors.New("msgpack archive: truncated attrs block")
+	}
+	a.Size = int64(binary.BigEndian.Uint64(b[:8]))
+	a.AtimeUnix = int64(binary.BigEndian.Uint64(b[8:16]))
+	pos := 16
+	tlen := int(b[pos])
+	pos++
+	if pos+tlen > len(b) {
+		return a, errors.New("msgpack archive: truncated attrs block")
+	}
+	a.CksumType = string(b[pos : pos+tlen])
+	pos += tlen
+	if pos >= len(b) {
+		return a, errors.New("msgpack archive: truncated attrs block")
+	}
+	vlen := int(b[pos])
+	pos++
+	if pos+vlen > len(b) {
+		return a, errors.New("msgpack archive: truncated attrs block")
+	}
+	a.CksumVal = string(b[pos : pos+vlen])
+	return a, nil
+}
+
+// readIndex re-reads the footer + trailing index block msgpackWriter.Fini
+// wrote, returning every member's {offset, size} so GetMsgpackMember can seek
+// straight to one record instead of scanning the whole body sequentially.
+func readIndex(fh *os.File) (index map[string]mpEntry, err error) {
+	st, err := fh.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if st.Size() < mpFooterSize {
+		return nil, errors.New("msgpack archive: too short, missing footer")
+	}
+	footer := make([]byte, mpFooterSize)
+	if _, err = fh.ReadAt(footer, st.Size()-mpFooterSize); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(footer[:4]) != mpMagic {
+		return nil, errors.New("msgpack archive: bad footer magic")
+	}
+	idxOff := int64(binary.BigEndian.Uint64(footer[4:12]))
+	count := binary.BigEndian.Uint32(footer[12:16])
+
+	idxSize := st.Size() - mpFooterSize - idxOff
+	buf := make([]byte, idxSize)
+	if _, err = fh.ReadAt(buf, idxOff); err != nil {
+		return nil, err
+	}
+
+	index = make(map[string]mpEntry, count)
+	pos := 0
+	for i := uint32(0); i < count; i++ {
+		if pos+4 > len(buf) {
+			return nil, errors.New("msgpack archive: truncated index block")
+		}
+		nameLen := int(binary.BigEndian.Uint32(buf[pos : pos+4]))
+		pos += 4
+		if pos+nameLen+8+8 > len(buf) {
+			return nil, errors.New("msgpack archive: truncated index block")
+		}
+		name := string(buf[pos : pos+nameLen])
+		pos += nameLen
+		offset := int64(binary.BigEndian.Uint64(buf[pos : pos+8]))
+		pos += 8
+		size := int64(binary.BigEndian.Uint64(buf[pos : pos+8]))
+		pos += 8
+		index[name] = mpEntry{Offset: offset, Size: size}
+	}
+	return index, nil
+}
+
+// mpMemberReader closes the underlying *os.File once the caller is done
+// reading one member's data - GetMsgpackMember opens fh solely to serve this
+// one member, unlike msgpackWriter which keeps it open across the whole
+// archive's lifetime.
+type mpMemberReader struct {
+	io.Reader
+	fh *os.File
+}
+
+func (r *mpMemberReader) Close() error { return r.fh.Close() }
+
+// GetMsgpackMember is the random-access GET-archpath counterpart to the
+// sequential write side above: it opens fqn, reads the trailing index
+// mpFooter/readIndex describe, looks up name, and returns a ReadCloser
+// positioned at exactly that member's data - without decoding any other
+// record in the archive - plus name's decoded MsgpackAttrs.
+func GetMsgpackMember(fqn, name string) (io.ReadCloser, MsgpackAttrs, error) {
+	fh, err := os.Open(fqn)
+	if err != nil {
+		return nil, MsgpackAttrs{}, err
+	}
+	index, err := readIndex(fh)
+	if err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	entry, ok := index[name]
+	if !ok {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, fmt.Errorf("msgpack archive %s: member %q not found", fqn, name)
+	}
+
+	if _, err = fh.Seek(entry.Offset, io.SeekStart); err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	var hdr [8]byte
+	if _, err = io.ReadFull(fh, hdr[:4]); err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	nameLen := binary.BigEndian.Uint32(hdr[:4])
+	if _, err = fh.Seek(int64(nameLen), io.SeekCurrent); err != nil { // skip the name, already matched via the index
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	if _, err = io.ReadFull(fh, hdr[:4]); err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	attrsLen := binary.BigEndian.Uint32(hdr[:4])
+	attrsBuf := make([]byte, attrsLen)
+	if _, err = io.ReadFull(fh, attrsBuf); err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	attrs, err := decodeAttrs(attrsBuf)
+	if err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	if _, err = io.ReadFull(fh, hdr[:8]); err != nil {
+		cos.Close(fh)
+		return nil, MsgpackAttrs{}, err
+	}
+	dataLen := int64(binary.BigEndian.Uint64(hdr[:8]))
+
+	return &mpMemberReader{Reader: io.LimitReader(fh, dataLen), fh: fh}, attrs, nil
+}
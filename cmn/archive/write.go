@@ -53,7 +53,9 @@ var (
 	_ Writer = (*zipWriter)(nil)
 )
 
-func NewWriter(mime string, w io.Writer, cksum *cos.CksumHashSize, serialize bool) (aw Writer) {
+// `compressionLevel` is optional and applies only to codecs that support it (currently: tar.zst);
+// when omitted, `DfltCompressionLevel` is used.
+func NewWriter(mime string, w io.Writer, cksum *cos.CksumHashSize, serialize bool, compressionLevel ...int) (aw Writer) {
 	switch mime {
 	case ExtTar:
 		aw = &tarWriter{}
@@ -61,6 +63,18 @@ func NewWriter(mime string, w io.Writer, cksum *cos.CksumHashSize, serialize boo
 		aw = &tgzWriter{}
 	case ExtZip:
 		aw = &zipWriter{}
+	case ExtMsgpack:
+		aw = &msgpackWriter{}
+	case ExtTarZst:
+		tzw := &tzstWriter{}
+		level := DfltCompressionLevel
+		if len(compressionLevel) > 0 && compressionLevel[0] > 0 {
+			level = compressionLevel[0]
+		}
+		tzw.initLevel(w, cksum, serialize, level)
+		return tzw
+	case ExtTarXz:
+		aw = &txzWriter{}
 	default:
 		debug.Assert(false, mime)
 	}
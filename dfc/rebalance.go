@@ -7,14 +7,158 @@ package dfc
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/golang/glog"
 )
 
+// daemonInfo is the subset of a cluster node's info that placement needs.
+// The full daemonInfo (IDs, networking, ...) lives in daemon.go elsewhere in
+// this package; Zone, Weight and Capacity are the fields this file adds to it.
+type daemonInfo struct {
+	DaemonID string
+	Zone     string  // rack/AZ identifier; "" means "no zone affinity data"
+	Weight   float64 // static per-node weight, e.g. proportional to disk count/spec; 0 < Weight, defaults to 1
+	Capacity float64 // percent-free [0..100]; 0 means "not yet reported"
+}
+
+// effectiveWeight folds the node's static Weight together with its current
+// free-capacity fraction, so a well-provisioned-but-nearly-full target still
+// loses out to a smaller-but-emptier one.
+func (d *daemonInfo) effectiveWeight() float64 {
+	w := d.Weight
+	if w <= 0 {
+		w = 1
+	}
+	if d.Capacity > 0 {
+		w *= d.Capacity / 100
+	}
+	const epsilon = 1e-9 // never return exactly zero: that would make -ln(u)/w diverge to +Inf
+	if w <= 0 {
+		w = epsilon
+	}
+	return w
+}
+
+// hashUnit derives a deterministic pseudo-random value in (0, 1] from
+// (key, daemonID) - the "u" in the weighted-rendezvous score below. Being a
+// pure function of (key, daemonID), every node computes the same score for
+// the same key without any coordination.
+func hashUnit(key, daemonID string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(daemonID))
+	sum := h.Sum64()
+	return (float64(sum) + 1) / (float64(^uint64(0)) + 1) // shift into (0, 1], never exactly 0
+}
+
+// rendezvousScore is the classic "weighted rendezvous hashing" / highest
+// random weight score, expressed as an exponential arrival time:
+// T_i = -ln(u_i) / w_i. Drawing one such T per node and keeping the minimum
+// is equivalent to sampling a node with probability proportional to w_i, and
+// unlike modulo-based sharding, only the keys whose winning node actually
+// changes need to move when the node set changes (see shouldMigrate).
+func rendezvousScore(key string, node *daemonInfo) float64 {
+	u := hashUnit(key, node.DaemonID)
+	return -math.Log(u) / node.effectiveWeight()
+}
+
+// rankTargets orders nodes by rendezvousScore ascending: the first entry is
+// the single best target for key (lowest simulated arrival time).
+func rankTargets(key string, nodes map[string]*daemonInfo) []*daemonInfo {
+	ranked := make([]*daemonInfo, 0, len(nodes))
+	for _, node := range nodes {
+		ranked = append(ranked, node)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		si, sj := rendezvousScore(key, ranked[i]), rendezvousScore(key, ranked[j])
+		if si != sj {
+			return si < sj
+		}
+		return ranked[i].DaemonID < ranked[j].DaemonID // stable tie-break
+	})
+	return ranked
+}
+
+// rankedZoneDiverseTargets walks the rendezvous ranking for key and collects
+// up to topN targets, preferring one target per distinct Zone first (so a
+// multi-copy placement spreads across failure domains) and only falling
+// back to a second target in an already-used zone once every zone has been
+// tried (i.e. zones are "exhausted" relative to topN).
+func rankedZoneDiverseTargets(key string, nodes map[string]*daemonInfo, topN int) []*daemonInfo {
+	ranked := rankTargets(key, nodes)
+	if topN <= 0 || topN > len(ranked) {
+		topN = len(ranked)
+	}
+	out := make([]*daemonInfo, 0, topN)
+	seenZone := make(map[string]bool, topN)
+	for _, node := range ranked {
+		if len(out) == topN {
+			return out
+		}
+		if node.Zone != "" && seenZone[node.Zone] {
+			continue
+		}
+		if node.Zone != "" {
+			seenZone[node.Zone] = true
+		}
+		out = append(out, node)
+	}
+	// zones exhausted before reaching topN: backfill from the same ranking,
+	// now allowing zone repeats, preserving overall rendezvous order
+	picked := make(map[string]bool, len(out))
+	for _, node := range out {
+		picked[node.DaemonID] = true
+	}
+	for _, node := range ranked {
+		if len(out) == topN {
+			break
+		}
+		if picked[node.DaemonID] {
+			continue
+		}
+		out = append(out, node)
+		picked[node.DaemonID] = true
+	}
+	return out
+}
+
+// shouldMigrate reports whether key's top rendezvous target differs between
+// the pre- and post-change node sets - the basis for migration-minimizing
+// rebalance: only keys whose winning target actually moved get re-copied,
+// instead of every object being re-evaluated and (at modulo-sharding odds)
+// almost all of them moving.
+func shouldMigrate(key string, prevNodes, currNodes map[string]*daemonInfo) bool {
+	prevTop := rankedZoneDiverseTargets(key, prevNodes, 1)
+	currTop := rankedZoneDiverseTargets(key, currNodes, 1)
+	if len(prevTop) == 0 || len(currTop) == 0 {
+		return len(prevTop) != len(currTop)
+	}
+	return prevTop[0].DaemonID != currTop[0].DaemonID
+}
+
+// capacityWeightedTarget picks the single best target for key via weighted
+// rendezvous hashing over t.smap.Smap: each target's score accounts for its
+// static Weight, its currently-reported free Capacity, and - through the
+// zone-diverse walk - its Zone, so placement naturally spreads across zones
+// without needing a separate pass. Falls back to plain unweighted HRW
+// whenever no node reports Weight/Capacity (every node then has Weight==1,
+// Capacity==0, which effectiveWeight treats as weight 1).
+func (t *targetrunner) capacityWeightedTarget(key string) (best *daemonInfo) {
+	top := rankedZoneDiverseTargets(key, t.smap.Smap, 1)
+	if len(top) == 0 {
+		return nil
+	}
+	return top[0]
+}
+
 func (t *targetrunner) runRebalance() {
 	xreb := t.xactinp.renewRebalance(t.smap.Version, t)
 	if xreb == nil {
@@ -65,7 +209,17 @@ func (xreb *xactRebalance) rewalkf(fqn string, osfi os.FileInfo, err error) erro
 	// rebalance this fobject maybe
 	t := xreb.targetrunner
 	mpath, bucket, objname := t.splitfqn(fqn)
-	si := hrwTarget(bucket+"/"+objname, t.smap)
+	key := bucket + "/" + objname
+	// xreb.prevSmap, when set by renewRebalance, is the placement snapshot
+	// from before the triggering membership change; skipping keys whose top
+	// target didn't move is what makes this a minimal-migration rebalance
+	// instead of a full re-shard.
+	if xreb.prevSmap != nil && !shouldMigrate(key, xreb.prevSmap.Smap, t.smap.Smap) {
+		glog.Infof("[%s %s %s] unaffected by membership change, staying at %s", mpath, bucket, objname, t.si.DaemonID)
+		glog.Flush()
+		return nil
+	}
+	si := t.capacityWeightedTarget(key)
 	if si.DaemonID != t.si.DaemonID {
 		glog.Infof("[%s %s %s] must be rebalanced from %s to %s", mpath, bucket, objname, t.si.DaemonID, si.DaemonID)
 		glog.Flush()
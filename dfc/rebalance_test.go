@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2017, NVIDIA CORPORATION. All rights reserved.
+ *
+ */
+package dfc
+
+import (
+	"fmt"
+	"testing"
+)
+
+func synthCluster(spec map[string]struct {
+	zone   string
+	weight float64
+}) map[string]*daemonInfo {
+	nodes := make(map[string]*daemonInfo, len(spec))
+	for id, s := range spec {
+		nodes[id] = &daemonInfo{DaemonID: id, Zone: s.zone, Weight: s.weight, Capacity: 100}
+	}
+	return nodes
+}
+
+func TestRendezvousScoreDeterministic(t *testing.T) {
+	nodes := synthCluster(map[string]struct {
+		zone   string
+		weight float64
+	}{
+		"t1": {"z1", 1}, "t2": {"z2", 1}, "t3": {"z3", 1},
+	})
+	const key = "bucket/object-1"
+	first := rankTargets(key, nodes)
+	for i := 0; i < 10; i++ {
+		again := rankTargets(key, nodes)
+		if len(again) != len(first) {
+			t.Fatalf("ranking length changed across calls")
+		}
+		for j := range first {
+			if first[j].DaemonID != again[j].DaemonID {
+				t.Fatalf("ranking not deterministic: call %d differs at position %d: %s != %s",
+					i, j, first[j].DaemonID, again[j].DaemonID)
+			}
+		}
+	}
+}
+
+func TestRendezvousScorePrefersHigherWeight(t *testing.T) {
+	nodes := synthCluster(map[string]struct {
+		zone   string
+		weight float64
+	}{
+		"heavy": {"z1", 100}, "light": {"z1", 1},
+	})
+	wins := map[string]int{}
+	const n = 500
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("bucket/object-%d", i)
+		top := rankedZoneDiverseTargets(key, nodes, 1)
+		wins[top[0].DaemonID]++
+	}
+	if wins["heavy"] <= wins["light"] {
+		t.Fatalf("expected heavier node to win the majority of keys, got %+v", wins)
+	}
+}
+
+func TestRankedZoneDiverseTargetsSpreadsAcrossZones(t *testing.T) {
+	nodes := synthCluster(map[string]struct {
+		zone   string
+		weight float64
+	}{
+		"t1": {"z1", 1}, "t2": {"z1", 1},
+		"t3": {"z2", 1}, "t4": {"z2", 1},
+		"t5": {"z3", 1},
+	})
+	top := rankedZoneDiverseTargets("bucket/object-42", nodes, 3)
+	if len(top) != 3 {
+		t.Fatalf("expected 3 targets, got %d", len(top))
+	}
+	zones := make(map[string]bool, 3)
+	for _, node := range top {
+		if zones[node.Zone] {
+			t.Fatalf("zone %s selected twice while other zones were still available: %+v", node.Zone, top)
+		}
+		zones[node.Zone] = true
+	}
+}
+
+func TestRankedZoneDiverseTargetsBackfillsWhenZonesExhausted(t *testing.T) {
+	nodes := synthCluster(map[string]struct {
+		zone   string
+		weight float64
+	}{
+		"t1": {"z1", 1}, "t2": {"z1", 1}, "t3": {"z2", 1},
+	})
+	top := rankedZoneDiverseTargets("bucket/object-7", nodes, 3)
+	if len(top) != 3 {
+		t.Fatalf("expected all 3 nodes back once zones (only 2) are exhausted, got %d: %+v", len(top), top)
+	}
+}
+
+func TestShouldMigrateOnlyFlagsKeysWhoseTopTargetChanged(t *testing.T) {
+	prev := synthCluster(map[string]struct {
+		zone   string
+		weight float64
+	}{
+		"t1": {"z1", 1}, "t2": {"z2", 1}, "t3": {"z3", 1},
+	})
+	curr := synthCluster(map[string]struct {
+		zone   string
+		weight float64
+	}{
+		"t1": {"z1", 1}, "t2": {"z2", 1}, "t3": {"z3", 1}, "t4": {"z4", 1},
+	})
+
+	var migrated, stayed int
+	const n = 300
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("bucket/object-%d", i)
+		if shouldMigrate(key, prev, curr) {
+			migrated++
+			prevTop := rankedZoneDiverseTargets(key, prev, 1)[0]
+			currTop := rankedZoneDiverseTargets(key, curr, 1)[0]
+			if prevTop.DaemonID == currTop.DaemonID {
+				t.Fatalf("shouldMigrate reported true for %s but top target did not change (%s)", key, currTop.DaemonID)
+			}
+		} else {
+			stayed++
+			prevTop := rankedZoneDiverseTargets(key, prev, 1)[0]
+			currTop := rankedZoneDiverseTargets(key, curr, 1)[0]
+			if prevTop.DaemonID != currTop.DaemonID {
+				t.Fatalf("shouldMigrate reported false for %s but top target changed %s -> %s", key, prevTop.DaemonID, currTop.DaemonID)
+			}
+		}
+	}
+	if migrated == 0 || stayed == 0 {
+		t.Fatalf("expected a mix of migrated/unaffected keys after adding a node, got migrated=%d stayed=%d", migrated, stayed)
+	}
+}
@@ -0,0 +1,269 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Meta is what Head reports about a remote object before Fetch begins. It's
+// the adapter-agnostic replacement for the raw `ETag`/`Last-Modified`
+// headers planResume used to read directly off an *http.Response - every
+// built-in and plugin adapter normalizes its own notion of a validator
+// (S3's ETag, GCS's generation, a plugin's own choice) into these fields so
+// planResume's "did the source change under us" check keeps working
+// regardless of scheme.
+type Meta struct {
+	Size         int64
+	ETag         string
+	LastModified string
+}
+
+// TransferAdapter fetches objects for one URL scheme. Head answers a plain
+// metadata query; Fetch streams bytes starting at `offset` (0 for a full
+// fetch, >0 to resume a partial per resume.go) into w. Built-in adapters for
+// http/https/file are registered by init() below (docker by docker.go's own
+// init). The gs/s3/az adapters are built with NewCloudAdapter and are not
+// registered by this package: whoever holds the CloudClient registers them
+// via RegisterAdapter, the same way sites add their own - built in process
+// or external.
+type TransferAdapter interface {
+	Scheme() string
+	Head(ctx context.Context, rawURL string) (Meta, error)
+	Fetch(ctx context.Context, rawURL string, offset int64, w io.Writer) error
+}
+
+// adapterRegistry maps a URL scheme to the adapter that serves it. A single
+// process-wide registry (mirroring infoStore/dedupPool's own package-level
+// singletons) keeps every worker goroutine looking schemes up through the
+// same table, including ones registered after startup by plugin config.
+type adapterRegistry struct {
+	mu sync.RWMutex
+	m  map[string]TransferAdapter
+}
+
+var adapters = &adapterRegistry{m: make(map[string]TransferAdapter)}
+
+// RegisterAdapter installs a into the registry under a.Scheme(), replacing
+// any adapter previously registered for that scheme. Built-ins register
+// themselves this way in init(); a plugin config does the same at cluster
+// startup once its subprocess has been started (see adapter_process.go).
+func RegisterAdapter(a TransferAdapter) {
+	adapters.mu.Lock()
+	defer adapters.mu.Unlock()
+	adapters.m[a.Scheme()] = a
+}
+
+func init() {
+	h := newHTTPAdapter(http.DefaultClient)
+	RegisterAdapter(h)              // "http"
+	RegisterAdapter(httpsAlias{h})  // "https"
+	RegisterAdapter(&fileAdapter{}) // "file"
+}
+
+// httpsAlias registers the same httpAdapter under the "https" scheme
+// without a second Scheme() value colliding with "http" in the registry.
+type httpsAlias struct{ *httpAdapter }
+
+func (httpsAlias) Scheme() string { return "https" }
+
+// schemeOf extracts the scheme adapterFor keys on, defaulting to "https"
+// for the protocol-less URLs existing callers already pass (e.g. a bare
+// "storage.googleapis.com/..." link) - the same assumption the pre-adapter
+// fetch path made implicitly by handing such URLs straight to an
+// *http.Client.
+func schemeOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return "https"
+	}
+	return u.Scheme
+}
+
+// adapterFor resolves the TransferAdapter registered for rawURL's scheme. A
+// ".torrent"/"magnet:" source (see torrent.go) is checked ahead of the
+// normal scheme lookup: a ".torrent" reference's outer scheme (http/https/
+// file, wherever the .torrent metadata file itself lives) is not what
+// actually serves the content, the embedded torrent client is.
+func adapterFor(rawURL string) (TransferAdapter, error) {
+	scheme := schemeOf(rawURL)
+	if isTorrentSource(rawURL) {
+		scheme = "magnet"
+	}
+	adapters.mu.RLock()
+	defer adapters.mu.RUnlock()
+	a, ok := adapters.m[scheme]
+	if !ok {
+		return nil, fmt.Errorf("downloader: no transfer adapter registered for scheme %q (url: %s)", scheme, rawURL)
+	}
+	return a, nil
+}
+
+// httpAdapter is the built-in TransferAdapter for "http"/"https", built
+// directly on top of rangeRequest/checkRangeResponse from resume.go
+// (dockerAdapter resumes through the same two). fetchResumableOnce does not
+// route http(s) urls through it: those use the If-Range GET in
+// fetchHTTPIfRange (ifrange.go) instead.
+type httpAdapter struct{ client *http.Client }
+
+func newHTTPAdapter(client *http.Client) *httpAdapter {
+	return &httpAdapter{client: client}
+}
+
+func (*httpAdapter) Scheme() string { return "http" }
+
+func (a *httpAdapter) Head(ctx context.Context, rawURL string) (Meta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return Meta{}, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return Meta{}, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return Meta{}, fmt.Errorf("downloader: HEAD %s: status %d", rawURL, resp.StatusCode)
+	}
+	return Meta{
+		Size:         resp.ContentLength,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+func (a *httpAdapter) Fetch(ctx context.Context, rawURL string, offset int64, w io.Writer) error {
+	req, err := rangeRequest(ctx, rawURL, offset)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkRangeResponse(resp, offset); err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("downloader: GET %s: status %d", rawURL, resp.StatusCode)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// fileAdapter is the built-in TransferAdapter for "file://", mainly useful
+// for on-prem sources mounted into the target (an NFS share staged as a
+// download source) and for tests that would otherwise need an HTTP server.
+type fileAdapter struct{}
+
+func (*fileAdapter) Scheme() string { return "file" }
+
+func (*fileAdapter) Head(_ context.Context, rawURL string) (Meta, error) {
+	path, err := filePath(rawURL)
+	if err != nil {
+		return Meta{}, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Meta{}, err
+	}
+	return Meta{Size: fi.Size(), LastModified: fi.ModTime().UTC().Format(http.TimeFormat)}, nil
+}
+
+func (*fileAdapter) Fetch(_ context.Context, rawURL string, offset int64, w io.Writer) error {
+	path, err := filePath(rawURL)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func filePath(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Path == "" {
+		return "", fmt.Errorf("downloader: malformed file:// url %q", rawURL)
+	}
+	return u.Path, nil
+}
+
+// CloudClient is the minimal, provider-agnostic object-read operation the
+// gs/s3/az adapters need. It's intentionally not redefined here in terms of
+// an SDK: it's satisfied by the same per-provider backend clients the
+// cluster's warm GET path already maintains (cluster.T.Backend-style
+// lookups keyed by cmn.ProviderGoogle/ProviderAmazon/ProviderAzure), so a
+// target wires a bckCloudClient into NewCloudAdapter once at startup rather
+// than this package pulling in the cloud SDKs itself.
+type CloudClient interface {
+	// HeadObj returns size and a provider validator (ETag/generation) for
+	// bucket/key without downloading it.
+	HeadObj(ctx context.Context, bucket, key string) (Meta, error)
+	// GetObj streams bucket/key starting at offset into w.
+	GetObj(ctx context.Context, bucket, key string, offset int64, w io.Writer) error
+}
+
+// cloudAdapter adapts one CloudClient to TransferAdapter for a given
+// scheme ("gs", "s3", "az"), parsing rawURL as scheme://bucket/key.
+type cloudAdapter struct {
+	scheme string
+	client CloudClient
+}
+
+// NewCloudAdapter builds and - via RegisterAdapter at the call site - wires
+// in the gs/s3/az TransferAdapter backed by client. Called once per
+// provider at target startup, after the corresponding cloud backend client
+// has itself finished initializing.
+func NewCloudAdapter(scheme string, client CloudClient) TransferAdapter {
+	return &cloudAdapter{scheme: scheme, client: client}
+}
+
+func (a *cloudAdapter) Scheme() string { return a.scheme }
+
+func (a *cloudAdapter) Head(ctx context.Context, rawURL string) (Meta, error) {
+	bucket, key, err := cloudBucketKey(rawURL)
+	if err != nil {
+		return Meta{}, err
+	}
+	return a.client.HeadObj(ctx, bucket, key)
+}
+
+func (a *cloudAdapter) Fetch(ctx context.Context, rawURL string, offset int64, w io.Writer) error {
+	bucket, key, err := cloudBucketKey(rawURL)
+	if err != nil {
+		return err
+	}
+	return a.client.GetObj(ctx, bucket, key, offset, w)
+}
+
+func cloudBucketKey(rawURL string) (bucket, key string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Host == "" || u.Path == "" {
+		return "", "", fmt.Errorf("downloader: malformed %s:// url %q, want %s://bucket/key", u.Scheme, rawURL, u.Scheme)
+	}
+	return u.Host, u.Path[1:], nil
+}
@@ -0,0 +1,175 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// stubAdapter is a minimal TransferAdapter used only to exercise registry
+// selection, without any real Head/Fetch behavior.
+type stubAdapter struct{ scheme string }
+
+func (s *stubAdapter) Scheme() string                                        { return s.scheme }
+func (*stubAdapter) Head(context.Context, string) (Meta, error)              { return Meta{}, nil }
+func (*stubAdapter) Fetch(context.Context, string, int64, io.Writer) error { return nil }
+
+func TestAdapterSelectionByScheme(t *testing.T) {
+	fake := &stubAdapter{scheme: "stub"}
+	RegisterAdapter(fake)
+
+	cases := []struct {
+		url    string
+		scheme string
+	}{
+		{"http://example.com/o", "http"},
+		{"https://example.com/o", "https"},
+		{"example.com/o", "https"}, // no scheme: same default the pre-adapter fetch path assumed
+		{"stub://bucket/o", "stub"},
+	}
+	for _, c := range cases {
+		a, err := adapterFor(c.url)
+		if err != nil {
+			t.Fatalf("adapterFor(%q): %v", c.url, err)
+		}
+		if a.Scheme() != c.scheme {
+			t.Errorf("adapterFor(%q) = scheme %q, want %q", c.url, a.Scheme(), c.scheme)
+		}
+	}
+
+	if _, err := adapterFor("ftp://example.com/o"); err == nil {
+		t.Error("expected adapterFor to fail for an unregistered scheme")
+	}
+}
+
+func TestHTTPAdapterHeadAndFetch(t *testing.T) {
+	const body = "http adapter payload"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", "20")
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	a := newHTTPAdapter(srv.Client())
+	meta, err := a.Head(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if meta.ETag != `"v1"` {
+		t.Errorf("Head ETag = %q, want %q", meta.ETag, `"v1"`)
+	}
+
+	var buf bytes.Buffer
+	if err := a.Fetch(context.Background(), srv.URL, 0, &buf); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if buf.String() != body {
+		t.Errorf("Fetch body = %q, want %q", buf.String(), body)
+	}
+}
+
+func TestFileAdapter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "obj")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &fileAdapter{}
+	meta, err := a.Head(context.Background(), "file://"+path)
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if meta.Size != 10 {
+		t.Errorf("Head Size = %d, want 10", meta.Size)
+	}
+
+	var buf bytes.Buffer
+	if err := a.Fetch(context.Background(), "file://"+path, 4, &buf); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if buf.String() != "456789" {
+		t.Errorf("Fetch from offset 4 = %q, want %q", buf.String(), "456789")
+	}
+}
+
+// buildFakeAdapter compiles the testdata fixture into a temp binary, or
+// skips the test if no Go toolchain is available to build it with.
+func buildFakeAdapter(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available to build the fakeadapter fixture")
+	}
+	bin := filepath.Join(t.TempDir(), "fakeadapter")
+	cmd := exec.Command("go", "build", "-o", bin, "./testdata/fakeadapter")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("building fakeadapter fixture: %v\n%s", err, out)
+	}
+	return bin
+}
+
+func TestProcessAdapterProtocol(t *testing.T) {
+	bin := buildFakeAdapter(t)
+	a, err := StartProcessAdapter(ProcessAdapterConfig{Scheme: "fake", Path: bin}, nil)
+	if err != nil {
+		t.Fatalf("StartProcessAdapter: %v", err)
+	}
+	defer a.(*processAdapter).Close()
+
+	meta, err := a.Head(context.Background(), "fake://bucket/ok")
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if meta.ETag != "fake-etag" {
+		t.Errorf("Head ETag = %q, want %q", meta.ETag, "fake-etag")
+	}
+
+	var buf bytes.Buffer
+	if err := a.Fetch(context.Background(), "fake://bucket/ok", 0, &buf); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("Fetch returned no bytes")
+	}
+}
+
+func TestProcessAdapterProgressAndErrors(t *testing.T) {
+	bin := buildFakeAdapter(t)
+	var reported []int64
+	a, err := StartProcessAdapter(ProcessAdapterConfig{Scheme: "fake", Path: bin}, func(bytesSoFar int64) {
+		reported = append(reported, bytesSoFar)
+	})
+	if err != nil {
+		t.Fatalf("StartProcessAdapter: %v", err)
+	}
+	defer a.(*processAdapter).Close()
+
+	var buf bytes.Buffer
+	if err := a.Fetch(context.Background(), "fake://bucket/ok", 0, &buf); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(reported) == 0 {
+		t.Error("expected at least one progress callback")
+	}
+
+	if _, err := a.Head(context.Background(), "fake://bucket/err"); err == nil {
+		t.Error("expected Head to propagate the fixture's simulated stat error")
+	}
+	if err := a.Fetch(context.Background(), "fake://bucket/err", 0, &buf); err == nil {
+		t.Error("expected Fetch to propagate the fixture's simulated download error")
+	}
+}
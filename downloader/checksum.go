@@ -0,0 +1,107 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// ChecksumType names the digest algorithm an ExpectedChecksum is expressed
+// in. xxhash is listed for parity with the cluster's own default object
+// checksum (cmn/cos.ChecksumXXHash, used throughout warm-GET validation)
+// but isn't computed by this package directly - see newHasher.
+type ChecksumType string
+
+const (
+	ChecksumXXHash ChecksumType = "xxhash"
+	ChecksumMD5    ChecksumType = "md5"
+	ChecksumSHA256 ChecksumType = "sha256"
+)
+
+// ExpectedChecksum is a {type, digest} pair for checksum-based skip/override
+// decisions (shouldSkip) in place of the source's opaque version string (the
+// `x-goog-generation` header `TestDownloadOverrideObject`/
+// `TestDownloadSkipObject` key off today), and for verifying a completed
+// fetch (verifyChecksum). It is not a field of `DlBase`/`DlSingleObj`, and
+// the fetch path (fetchResumable) calls neither helper.
+type ExpectedChecksum struct {
+	Type  ChecksumType `json:"type"`
+	Value string       `json:"value"`
+}
+
+// errChecksumMismatch is returned by verifyChecksum; its error string embeds
+// both digests.
+type errChecksumMismatch struct {
+	expected, got string
+}
+
+func (e *errChecksumMismatch) Error() string {
+	return fmt.Sprintf("downloader: checksum mismatch: expected %s, got %s", e.expected, e.got)
+}
+
+// newHasher returns the hash.Hash that computes digests of type t. xxhash
+// isn't implemented here: the cluster already maintains an xxhash object
+// checksum end to end via cmn/cos, and that's what a full build wires in
+// for ChecksumXXHash rather than this package vendoring a second xxhash
+// implementation.
+func newHasher(t ChecksumType) (hash.Hash, error) {
+	switch t {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumXXHash:
+		return nil, fmt.Errorf("downloader: checksum type %q requires the cluster's cos.CksumHash, not available to this package standalone", t)
+	default:
+		return nil, fmt.Errorf("downloader: unknown checksum type %q", t)
+	}
+}
+
+// shouldSkip decides whether an object already on disk with digest
+// existing can stand in for a fetch pinned to expected, replacing the
+// version-string comparison `TestDownloadSkipObject` exercises today. Types
+// must match - a stored md5 can't confirm an expected sha256 - and an
+// empty existing value (nothing on disk yet, or it was never checksummed)
+// never matches.
+func shouldSkip(existing, expected ExpectedChecksum) bool {
+	return existing.Value != "" && existing.Type == expected.Type && existing.Value == expected.Value
+}
+
+// verifyChecksum streams src into dst through a TeeReader hashing with
+// expected.Type, so the download and the digest computation happen in the
+// same pass rather than a second read-back over the finished file. It
+// returns errChecksumMismatch (embedding both digests) if the computed
+// digest doesn't match
+// expected.Value; expected.Value == "" skips verification entirely and
+// just returns the computed digest, for the "record but don't enforce"
+// case of a plain (non-override) checksummed download. When no
+// verification was requested - including the zero-value
+// ExpectedChecksum{} every unchecksummed download passes - expected.Type
+// need not even name a type newHasher understands: src is copied to dst
+// unhashed and digest comes back empty instead of surfacing newHasher's
+// error.
+func verifyChecksum(dst io.Writer, src io.Reader, expected ExpectedChecksum) (digest string, err error) {
+	h, herr := newHasher(expected.Type)
+	if herr != nil {
+		if expected.Value == "" {
+			_, err = io.Copy(dst, src)
+			return "", err
+		}
+		return "", herr
+	}
+	if _, err := io.Copy(dst, io.TeeReader(src, h)); err != nil {
+		return "", err
+	}
+	digest = hex.EncodeToString(h.Sum(nil))
+	if expected.Value != "" && digest != expected.Value {
+		return digest, &errChecksumMismatch{expected: expected.Value, got: digest}
+	}
+	return digest, nil
+}
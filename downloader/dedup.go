@@ -0,0 +1,161 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"sync"
+)
+
+// fetchResult is what every subscriber of an in-flight fetch eventually
+// receives: either a completed object (size + checksum) or the error that
+// aborted it.
+type fetchResult struct {
+	size     int64
+	checksum string
+	err      error
+}
+
+// inflight tracks a single (bucket, objName, URL) fetch that more than one
+// job/task wants right now. Only the first caller actually performs the
+// HTTP request; every later caller subscribes to `waiters` and inherits the
+// same result - modeled on Docker's `poolAdd("pull", ...)` coalescing.
+// Waiters are keyed by jobID rather than held as an anonymous slice because
+// the leader belongs to a different job than its waiters and, on success,
+// has to copy the bytes it fetched into each waiter's own per-job partial
+// (see finishSharedFetch in ifrange.go) - that needs to know which jobID
+// each channel belongs to, not just that a channel exists.
+type inflight struct {
+	cancel  context.CancelFunc
+	waiters map[string]chan fetchResult // jobID -> that job's completion channel
+}
+
+// dedupPool coalesces concurrent fetches of the same (bucket, objName, URL)
+// within a single target, so two jobs racing to download the same object
+// from the same source cost one network transfer instead of two.
+type dedupPool struct {
+	mu sync.Mutex
+	m  map[string]*inflight
+}
+
+func newDedupPool() *dedupPool {
+	return &dedupPool{m: make(map[string]*inflight)}
+}
+
+func dedupKey(bucket, objName, url string) string {
+	return bucket + "\x00" + objName + "\x00" + url
+}
+
+// join registers jobID as a waiter on an existing fetch for `key`, or, if
+// none is in flight, starts one (`leader == true`) and returns leaderCtx, a
+// child of ctx for the leader's fetch that `leave` cancels once the last
+// waiter has dropped out. Non-leaders get a nil leaderCtx.
+func (p *dedupPool) join(ctx context.Context, key, jobID string) (ch chan fetchResult, leader bool, leaderCtx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch = make(chan fetchResult, 1)
+	if f, ok := p.m[key]; ok {
+		f.waiters[jobID] = ch
+		return ch, false, nil
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	p.m[key] = &inflight{cancel: cancel, waiters: map[string]chan fetchResult{jobID: ch}}
+	return ch, true, leaderCtx
+}
+
+// leave drops jobID's waiter out of an in-flight fetch (e.g. its own job
+// was aborted while the fetch continues for other subscribers). If it was
+// the last subscriber, the underlying fetch is canceled.
+func (p *dedupPool) leave(key, jobID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, ok := p.m[key]
+	if !ok {
+		return
+	}
+	delete(f.waiters, jobID)
+	if len(f.waiters) == 0 {
+		f.cancel()
+		delete(p.m, key)
+	}
+}
+
+// otherWaiters reports the jobIDs (excluding leaderJobID) currently sharing
+// key's in-flight fetch, so the leader can copy its completed bytes into
+// each of their own per-job partials before publish - see
+// finishSharedFetch in ifrange.go.
+func (p *dedupPool) otherWaiters(key, leaderJobID string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, ok := p.m[key]
+	if !ok {
+		return nil
+	}
+	ids := make([]string, 0, len(f.waiters))
+	for jobID := range f.waiters {
+		if jobID != leaderJobID {
+			ids = append(ids, jobID)
+		}
+	}
+	return ids
+}
+
+// publish delivers res to every current waiter (including the leader's own
+// channel) and removes the entry, since the fetch is now done.
+func (p *dedupPool) publish(key string, res fetchResult) {
+	p.mu.Lock()
+	f, ok := p.m[key]
+	if ok {
+		delete(p.m, key)
+	}
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+	for _, w := range f.waiters {
+		w <- res
+		close(w)
+	}
+}
+
+// dedupStats counts fetches served by an already in-flight sibling, read
+// through DeduplicatedCount so tests can confirm a burst of identical
+// requests resulted in exactly one network fetch. No DownloadStatus field
+// carries it.
+type dedupStats struct {
+	mu           sync.Mutex
+	deduplicated int64
+}
+
+func (s *dedupStats) incDeduplicated() {
+	s.mu.Lock()
+	s.deduplicated++
+	s.mu.Unlock()
+}
+
+func (s *dedupStats) get() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deduplicated
+}
+
+// dedup is the process-wide pool fetchResumable (ifrange.go) joins before
+// every fetch, so concurrent tasks across different jobs - not just within
+// one - share a single in-flight transfer for the same (bucket, objName,
+// url).
+var dedup = newDedupPool()
+
+// dedupStatsGlobal backs DeduplicatedCount below; a single process-wide
+// counter, same scope as dedup itself.
+var dedupStatsGlobal = &dedupStats{}
+
+// DeduplicatedCount reports how many fetches since process start were
+// served by an already in-flight sibling instead of hitting the source
+// themselves. It is process-wide, not per job.
+func DeduplicatedCount() int64 { return dedupStatsGlobal.get() }
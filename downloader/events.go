@@ -0,0 +1,217 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind identifies what stage of a single object's fetch an Event
+// reports, from the moment it's admitted into a job through its terminal
+// outcome.
+type EventKind string
+
+const (
+	EvScheduled EventKind = "Scheduled" // admitted into the job, not yet dispatched to a worker
+	EvStarted   EventKind = "Started"   // worker picked a URL and began fetching it
+	EvProgress  EventKind = "Progress"  // throttled bytes-so-far update, see progressThrottle
+	EvRetry     EventKind = "Retry"     // withRetry is about to reattempt after a transient error
+	EvFinished  EventKind = "Finished"  // object fully fetched, checksummed and handed off
+	EvFailed    EventKind = "Failed"    // object permanently failed (retries exhausted or non-retryable)
+	EvAborted   EventKind = "Aborted"   // job aborted before this object finished
+	EvDropped   EventKind = "Dropped"   // marker: `Dropped` older events were evicted from the ring before a subscriber saw them
+)
+
+// Event is one entry in a job's event stream, as published by
+// eventRing.publish and consumed by api.DownloadEvents on the client side
+// (see the doc comment at the bottom of this file for how that wires
+// through the proxy). Only the fields relevant to Kind are populated.
+type Event struct {
+	Kind     EventKind `json:"kind"`
+	JobID    string    `json:"job_id"`
+	ObjName  string    `json:"obj_name,omitempty"`
+	Seq      int64     `json:"seq"`              // monotonic, assigned by the publishing target's ring
+	Target   string    `json:"target,omitempty"` // target daemon ID; set by the proxy's fan-in, empty on a target's own ring
+	URL      string    `json:"url,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Bytes    int64     `json:"bytes,omitempty"`
+	Total    int64     `json:"total,omitempty"`
+	Attempt  int       `json:"attempt,omitempty"`
+	Checksum string    `json:"checksum,omitempty"`
+	Err      string    `json:"err,omitempty"`
+	Dropped  int64     `json:"dropped,omitempty"`
+	At       time.Time `json:"at"` // when this target observed/published the event, used client-side to derive transfer speed between two Progress events for the same ObjName
+}
+
+const defaultEventRingCapacity = 256
+
+// eventRing is the bounded, per-jobID event history plus live subscriber
+// fan-out a target maintains for one download job. It never blocks the
+// worker that calls publish: a full ring drops its oldest entry, and a
+// subscriber channel that isn't being drained fast enough just misses the
+// event rather than backing up the publisher.
+type eventRing struct {
+	mu       sync.Mutex
+	jobID    string
+	capacity int
+	seq      int64
+	buf      []Event
+	subs     map[chan Event]struct{}
+}
+
+func newEventRing(jobID string, capacity int) *eventRing {
+	if capacity <= 0 {
+		capacity = defaultEventRingCapacity
+	}
+	return &eventRing{jobID: jobID, capacity: capacity, subs: make(map[chan Event]struct{})}
+}
+
+// publish assigns the next sequence number to e, retains it in the ring
+// (evicting the oldest entry once full), and pushes it to every current
+// subscriber on a best-effort basis.
+func (r *eventRing) publish(e Event) {
+	r.mu.Lock()
+	r.seq++
+	e.JobID = r.jobID
+	e.Seq = r.seq
+	e.At = time.Now()
+	if len(r.buf) >= r.capacity {
+		r.buf = append(r.buf[1:], e)
+	} else {
+		r.buf = append(r.buf, e)
+	}
+	subs := make([]chan Event, 0, len(r.subs))
+	for ch := range r.subs {
+		subs = append(subs, ch)
+	}
+	r.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- e:
+		default:
+			// subscriber isn't keeping up; it'll see an EvDropped marker on
+			// its next subscribe(since=...) instead of stalling the worker.
+		}
+	}
+}
+
+// subscribe registers ch for future events and replays anything still in
+// the ring newer than `since` (0 replays the whole retained ring), so a
+// reconnecting long-poll/SSE client - the proxy's fan-in, concretely -
+// doesn't miss events that landed between its last read and this call. If
+// the ring has already evicted events the caller hasn't seen, a single
+// EvDropped is sent first, naming how many were lost.
+//
+// The returned unsubscribe must be called when the caller stops reading
+// from ch; the caller should keep draining ch for a moment afterward, since
+// a publish concurrent with unsubscribe may already be attempting a send.
+func (r *eventRing) subscribe(ch chan Event, since int64) (unsubscribe func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.buf) > 0 && r.buf[0].Seq > since+1 {
+		dropped := r.buf[0].Seq - since - 1
+		select {
+		case ch <- Event{Kind: EvDropped, JobID: r.jobID, Dropped: dropped}:
+		default:
+		}
+	}
+	for _, e := range r.buf {
+		if e.Seq > since {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+
+	r.subs[ch] = struct{}{}
+	return func() {
+		r.mu.Lock()
+		delete(r.subs, ch)
+		r.mu.Unlock()
+	}
+}
+
+// progressThrottle rate-limits how often a worker emits EvProgress for a
+// single object's fetch, so a fast local source doesn't flood the ring with
+// an event per io.Copy chunk. A call with final=true always goes through
+// regardless of timing; fetchResumableOnce only makes final=false calls, so
+// the last chunks of a fetch may land without an EvProgress of their own.
+type progressThrottle struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func newProgressThrottle(interval time.Duration) *progressThrottle {
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	return &progressThrottle{interval: interval}
+}
+
+func (p *progressThrottle) allow(final bool) bool {
+	if final {
+		return true
+	}
+	now := time.Now()
+	if !p.last.IsZero() && now.Sub(p.last) < p.interval {
+		return false
+	}
+	p.last = now
+	return true
+}
+
+// eventRegistry maps a job ID to its eventRing, mirroring infoStore/
+// dedupPool's own package-level-singleton-plus-per-key-map shape.
+type eventRegistry struct {
+	mu sync.Mutex
+	m  map[string]*eventRing
+}
+
+var jobEvents = &eventRegistry{m: make(map[string]*eventRing)}
+
+// ringFor returns (creating if necessary) the eventRing for jobID.
+func ringFor(jobID string) *eventRing {
+	jobEvents.mu.Lock()
+	defer jobEvents.mu.Unlock()
+	r, ok := jobEvents.m[jobID]
+	if !ok {
+		r = newEventRing(jobID, defaultEventRingCapacity)
+		jobEvents.m[jobID] = r
+	}
+	return r
+}
+
+// dropRing discards jobID's ring, called once a finished job's status has
+// aged out of infoStore - after that point there's nothing left to
+// subscribe to.
+func dropRing(jobID string) {
+	jobEvents.mu.Lock()
+	delete(jobEvents.m, jobID)
+	jobEvents.mu.Unlock()
+}
+
+// Within this package, ifrange.go's fetchResumable/fetchResumableOnce are
+// the real publishers: EvStarted/EvProgress/EvRetry fire over the course of
+// one object's fetch, and every fetchResumable exit path - leader, waiter,
+// or context-cancelled - publishes exactly one of EvFinished/EvFailed/
+// EvAborted as its last act. EvScheduled and a job-wide EvAborted (a whole
+// job cancelled before some of its objects were even dispatched) are job-
+// level, not per-object, and so - like `DlBase.NotifyURL`'s webhook.go
+// completion hook - belong to the target's dispatcher outside this
+// package's present snapshot, the same boundary taskRedispatcher
+// (infostore.go) draws for redispatch.
+//
+// The client-side entry point is `api.DownloadEvents(baseParams, id)
+// (<-chan Event, error)`. It hits a new proxy endpoint that either upgrades
+// to server-sent events or long-polls a `since` cursor (whichever the
+// client's Accept header asks for); the proxy subscribes to every target's
+// `ringFor(id)` over the existing intra-cluster stream, merges by each
+// Event's (Target, Seq) pair so a client reconnecting after a drop resumes
+// from exactly where it left off per target, and republishes the merged
+// order on its own ring back to the caller.
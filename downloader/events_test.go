@@ -0,0 +1,134 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventRingPublishAndSubscribe(t *testing.T) {
+	r := newEventRing("job1", 4)
+	ch := make(chan Event, 8)
+	unsub := r.subscribe(ch, 0)
+	defer unsub()
+
+	r.publish(Event{Kind: EvScheduled, ObjName: "o1"})
+	r.publish(Event{Kind: EvStarted, ObjName: "o1", URL: "http://x"})
+
+	for i, want := range []EventKind{EvScheduled, EvStarted} {
+		select {
+		case e := <-ch:
+			if e.Kind != want {
+				t.Errorf("event %d: Kind = %q, want %q", i, e.Kind, want)
+			}
+			if e.JobID != "job1" {
+				t.Errorf("event %d: JobID = %q, want job1", i, e.JobID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+}
+
+func TestEventRingEvictionEmitsDropped(t *testing.T) {
+	r := newEventRing("job1", 2)
+	for i := 0; i < 5; i++ {
+		r.publish(Event{Kind: EvProgress, Bytes: int64(i)})
+	}
+
+	ch := make(chan Event, 8)
+	unsub := r.subscribe(ch, 0)
+	defer unsub()
+
+	first := <-ch
+	if first.Kind != EvDropped {
+		t.Fatalf("expected first replayed event to be EvDropped, got %q", first.Kind)
+	}
+	if first.Dropped != 3 {
+		t.Errorf("Dropped = %d, want 3 (5 published, ring capacity 2)", first.Dropped)
+	}
+}
+
+func TestEventRingSubscribeSinceCursor(t *testing.T) {
+	r := newEventRing("job1", 8)
+	r.publish(Event{Kind: EvScheduled})
+	r.publish(Event{Kind: EvStarted})
+
+	ch := make(chan Event, 8)
+	unsub := r.subscribe(ch, 1) // already saw seq 1
+	defer unsub()
+
+	select {
+	case e := <-ch:
+		if e.Kind != EvStarted || e.Seq != 2 {
+			t.Errorf("expected only seq 2 (EvStarted) to replay, got Kind=%q Seq=%d", e.Kind, e.Seq)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replayed event")
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected extra event replayed: %+v", e)
+	default:
+	}
+}
+
+func TestProgressThrottle(t *testing.T) {
+	p := newProgressThrottle(50 * time.Millisecond)
+	if !p.allow(false) {
+		t.Error("first call should always be allowed")
+	}
+	if p.allow(false) {
+		t.Error("immediate second call should be throttled")
+	}
+	if !p.allow(true) {
+		t.Error("final call must always be allowed regardless of timing")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !p.allow(false) {
+		t.Error("call after interval elapsed should be allowed")
+	}
+}
+
+func TestNotifyCompletionSigned(t *testing.T) {
+	const secret = "s3cr3t"
+	var gotSig string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(signatureHeader)
+		buf := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(buf)
+		gotBody = buf
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	summary := CompletionSummary{JobID: "job1", Bucket: "bck", Total: 2, Finished: 2}
+	err := notifyCompletion(context.Background(), srv.Client(), NotifyConfig{URL: srv.URL, Secret: secret}, summary)
+	if err != nil {
+		t.Fatalf("notifyCompletion: %v", err)
+	}
+
+	wantSig := "sha256=" + signBody(secret, gotBody)
+	if gotSig != wantSig {
+		t.Errorf("signature header = %q, want %q", gotSig, wantSig)
+	}
+}
+
+func TestNotifyCompletionNonSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	err := notifyCompletion(context.Background(), srv.Client(), NotifyConfig{URL: srv.URL}, CompletionSummary{JobID: "job1"})
+	if err == nil {
+		t.Error("expected an error for a non-2xx webhook response")
+	}
+}
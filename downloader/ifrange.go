@@ -0,0 +1,413 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// TaskRetryInfo is the per-object detail `DownloadStatusResp.CurrentTasks`
+// grows: how many retries (attempts beyond the first) withRetry made, the
+// error that failed the object (cleared once any attempt succeeds), the
+// byte offset `planResume` resumed from, if any, the URL that
+// actually supplied the object once fetchWithMirrors picks a winner (the
+// primary `link`, or whichever mirror it fell back to), the completed
+// object's size/checksum - for a dedup leader these come from its own
+// fetch, for a waiter they're copied from the leader's fetchResult so a
+// coalesced fetch reports the same size/checksum the leader did - and, for
+// a fresh (non-resuming) fetch split across perObjectConnections > 1
+// connections by fetchHTTPMultiRange, how many of the object's byteRanges
+// have completed.
+type TaskRetryInfo struct {
+	Retries     int    `json:"retries"`
+	LastError   string `json:"last_error,omitempty"`
+	ResumedFrom int64  `json:"resumed_from,omitempty"`
+	SourceURL   string `json:"source_url,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Checksum    string `json:"checksum,omitempty"`
+	RangesTotal int    `json:"ranges_total,omitempty"`
+	RangesDone  int    `json:"ranges_done,omitempty"`
+}
+
+// ifRangeRequest builds a GET for url that asks to resume at offset via a
+// conditional Range request: If-Range carries m's ETag (preferred) or
+// Last-Modified validator, so the server itself decides in a single round
+// trip whether the bytes already on disk can still be trusted - a 206
+// confirms they can, a 200 means the source changed underneath us and the
+// full object follows from byte 0. This replaces planResume's separate HEAD
+// for every retry after the first: the first attempt still calls planResume
+// once to decide whether there's a partial worth trying at all, but each
+// subsequent retry's own GET carries its own answer.
+func ifRangeRequest(ctx context.Context, url string, offset int64, m *partialMeta) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset <= 0 {
+		return req, nil
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	switch {
+	case m.ETag != "":
+		req.Header.Set("If-Range", m.ETag)
+	case m.LastModified != "":
+		req.Header.Set("If-Range", m.LastModified)
+	}
+	return req, nil
+}
+
+// ifRangeStale reports whether an ifRangeRequest's response means the
+// on-disk partial must be discarded: either the server ignored Range
+// (200 instead of 206) or the If-Range validator didn't match.
+func ifRangeStale(resp *http.Response, offset int64) bool {
+	return offset > 0 && resp.StatusCode != http.StatusPartialContent
+}
+
+// partialWriter appends every Write to the on-disk partial + sidecar via
+// appendPartial, so io.Copy's incremental chunking means a mid-body
+// disconnect still leaves whatever arrived before the error durably
+// flushed - exactly the bytes the next retry's Range request resumes from.
+type partialWriter struct {
+	dataPath, metaPath string
+	meta               *partialMeta
+	progress           func(bytesSoFar int64)
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	if err := appendPartial(w.dataPath, w.metaPath, w.meta, p); err != nil {
+		return 0, err
+	}
+	if w.progress != nil {
+		w.progress(w.meta.Written)
+	}
+	return len(p), nil
+}
+
+// fetchResumable performs one object's fetch end to end, first coalescing
+// with any other task already fetching the same (bucket, objName, url) via
+// dedup: two jobs racing to download the same object from the same source
+// are, by construction, going to end up with identical bytes at the same
+// destination, so only the first (the "leader") actually does the HTTP
+// round trip - every later caller waits for the leader's result instead of
+// repeating the transfer. Leaders fall through to fetchWithMirrors
+// (mirror.go), trying url and then mirrors in order until one succeeds;
+// each attempt goes through fetchResumableOnce, which does the real work:
+// planResume to decide a starting offset, ifRangeRequest/withRetry to drive
+// attempts against that one URL, and appendPartial (via partialWriter) to
+// persist progress, so a transient failure retries with Range/If-Range
+// against whatever's already on disk instead of restarting at byte 0 - and
+// a URL that exhausts its own retries falls through to the next mirror
+// rather than failing the object outright. perObjectConnections (the
+// `DlLimits.PerObjectConnections` job/task knob, default/1 meaning "no
+// split") is forwarded to fetchResumableOnce, which only honors it on a
+// fresh fetch; a resume always falls back to the single If-Range stream,
+// since fetchMultiRangeParallel has no way to pick up a partial range set
+// where a prior attempt left off. The caller is responsible for calling
+// finalizePartial once the completed bytes have been handed off into the
+// bucket, same as any other use of resume.go's partial machinery. Every exit
+// path - leader and waiter alike - publishes the matching Ev* lifecycle
+// event (events.go) to ringFor(jobID) as its last act, so a subscriber to
+// the job's event stream sees exactly one terminal event (EvFinished,
+// EvFailed or EvAborted) per object regardless of which path produced it.
+func fetchResumable(ctx context.Context, client *http.Client, policy *RetryPolicy, workfs, bucket, jobID, objName, url string, mirrors []string, randomize bool, perObjectConnections int) (info TaskRetryInfo, err error) {
+	key := dedupKey(bucket, objName, url)
+	ch, leader, leaderCtx := dedup.join(ctx, key, jobID)
+	if !leader {
+		select {
+		case res := <-ch:
+			dedupStatsGlobal.incDeduplicated()
+			if res.err != nil {
+				publishEvent(jobID, objName, url, EvFailed, 0, 0, res.err)
+				return info, res.err
+			}
+			info.Size = res.size
+			info.Checksum = res.checksum
+			publishEvent(jobID, objName, url, EvFinished, res.size, res.size, nil)
+			return info, nil
+		case <-ctx.Done():
+			dedup.leave(key, jobID)
+			publishEvent(jobID, objName, url, EvAborted, 0, 0, ctx.Err())
+			return info, ctx.Err()
+		}
+	}
+
+	ringFor(jobID).publish(Event{Kind: EvStarted, ObjName: objName, URL: url})
+
+	mres, ferr := fetchWithMirrors(leaderCtx, url, mirrors, randomize, func(ctx context.Context, u string) (int, error) {
+		once, oerr := fetchResumableOnce(ctx, client, policy, workfs, jobID, objName, u, perObjectConnections)
+		info.ResumedFrom = once.ResumedFrom
+		info.LastError = once.LastError
+		info.RangesTotal = once.RangesTotal
+		info.RangesDone = once.RangesDone
+		return once.Retries + 1, oerr
+	})
+	info.Retries = mres.attempts - 1
+	info.SourceURL = mres.url
+	if ferr == nil {
+		info.LastError = ""
+	}
+
+	res := fetchResult{err: ferr}
+	if ferr == nil {
+		res.size, res.checksum, ferr = finishSharedFetch(workfs, key, jobID, objName)
+		info.Size, info.Checksum = res.size, res.checksum
+	}
+	dedup.publish(key, res)
+
+	if ferr != nil {
+		kind := EvFailed
+		if leaderCtx.Err() != nil {
+			kind = EvAborted
+		}
+		publishEvent(jobID, objName, mres.url, kind, 0, 0, ferr)
+	} else {
+		publishEvent(jobID, objName, mres.url, EvFinished, res.size, res.size, nil)
+	}
+	return info, ferr
+}
+
+// publishEvent is the shared tail end of every fetchResumable exit path: it
+// fills in an Event for kind (Bytes/Total only meaningful for EvFinished)
+// and hands it to jobID's ring, stringifying err (nil becomes "") the same
+// way TaskRetryInfo.LastError does.
+func publishEvent(jobID, objName, url string, kind EventKind, bytes, total int64, err error) {
+	e := Event{Kind: kind, ObjName: objName, URL: url, Bytes: bytes, Total: total}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	ringFor(jobID).publish(e)
+}
+
+// finishSharedFetch runs after the leader's own fetch succeeds: every other
+// job currently sharing this dedup key (dedup.otherWaiters) gets the
+// leader's bytes copied into its own partialPaths(workfs, jobID, objName)
+// location, since each waiter will go on to finalize its own on-disk
+// partial exactly as if it had fetched the object itself. It also computes
+// the digest fetchResult.checksum carries, so a waiter's status reporting
+// isn't left blank the way a bare `fetchResult{err: err}` leaves it.
+func finishSharedFetch(workfs, key, leaderJobID, objName string) (size int64, checksum string, err error) {
+	leaderData, _ := partialPaths(workfs, leaderJobID, objName)
+	size, checksum, err = sumFile(leaderData)
+	if err != nil {
+		return 0, "", err
+	}
+	for _, jobID := range dedup.otherWaiters(key, leaderJobID) {
+		waiterData, waiterMeta := partialPaths(workfs, jobID, objName)
+		if cerr := copyPartial(leaderData, waiterData, waiterMeta); cerr != nil {
+			return size, checksum, cerr
+		}
+	}
+	return size, checksum, nil
+}
+
+// copyPartial duplicates the leader's completed data file into a waiter's
+// own partial path so the waiter's later finalize/promote step has bytes
+// of its own to work with - it never shared the leader's fetch goroutine or
+// on-disk paths, only the dedup result.
+func copyPartial(srcData, dstData, dstMeta string) error {
+	raw, err := os.ReadFile(srcData)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstData), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dstData, raw, 0o644); err != nil {
+		return err
+	}
+	return savePartialMeta(dstMeta, &partialMeta{Written: int64(len(raw))})
+}
+
+// sumFile reports path's size and sha256 digest, used to populate
+// fetchResult.size/checksum once a fetch has completed.
+func sumFile(path string) (size int64, checksum string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fetchResumableOnce is fetchResumable's actual transfer, run exactly once
+// per dedup key by whichever caller won the race to be its leader. A
+// "http"/"https" url (other than a ".torrent" reference, see
+// isTorrentSource) keeps using the richer If-Range-conditional GET built
+// directly on client (ifRangeRequest/ifRangeStale let the server itself
+// decide, in one round trip, whether a stale partial must be discarded -
+// something no TransferAdapter exposes); every other scheme adapterFor
+// resolves - file://, s3://, gs://, az://, docker://, magnet: among them -
+// goes through that scheme's adapter instead, since an *http.Client simply
+// can't serve it. The `fetch` closure handed to withRetry publishes
+// EvRetry itself (rather than threading jobID/objName into withRetry,
+// which stays a generic attempts-and-backoff driver with no event
+// awareness) before every invocation past the first, and progress -
+// throttled via progressThrottle so a fast local source doesn't flood the
+// ring - publishes EvProgress from inside partialWriter.Write as bytes
+// actually land on disk.
+func fetchResumableOnce(ctx context.Context, client *http.Client, policy *RetryPolicy, workfs, jobID, objName, url string, perObjectConnections int) (info TaskRetryInfo, err error) {
+	dataPath, metaPath := partialPaths(workfs, jobID, objName)
+
+	scheme := schemeOf(url)
+	httpLike := (scheme == "http" || scheme == "https") && !isTorrentSource(url)
+
+	var adapter TransferAdapter
+	if !httpLike {
+		adapter, err = adapterFor(url)
+		if err != nil {
+			return info, err
+		}
+	}
+
+	head := httpHeadFunc(client, url)
+	if !httpLike {
+		head = adapterHeadFunc(adapter, url)
+	}
+
+	offset, m, perr := planResume(ctx, head, workfs, jobID, objName, url, true)
+	if perr != nil {
+		return info, perr
+	}
+	info.ResumedFrom = offset
+
+	// A multi-range split only makes sense for a fresh fetch (offset == 0):
+	// fetchMultiRangeParallel has no concept of "resume ranges 2 and 3 only",
+	// so any offset > 0 (an actual resume) falls back to the single If-Range
+	// stream below, same as perObjectConnections <= 1 would.
+	if httpLike && offset == 0 && perObjectConnections > 1 {
+		rangesTotal, rangesDone, merr := fetchHTTPMultiRange(ctx, client, url, dataPath, metaPath, m, perObjectConnections)
+		info.RangesTotal, info.RangesDone = rangesTotal, rangesDone
+		if merr != nil {
+			info.LastError = merr.Error()
+			return info, merr
+		}
+		return info, nil
+	}
+
+	throttle := newProgressThrottle(0)
+	progress := func(bytesSoFar int64) {
+		if throttle.allow(false) {
+			ringFor(jobID).publish(Event{Kind: EvProgress, ObjName: objName, URL: url, Bytes: bytesSoFar})
+		}
+	}
+
+	attempt := 0
+	fetch := func(ctx context.Context) (int, error) {
+		if attempt > 0 {
+			ringFor(jobID).publish(Event{Kind: EvRetry, ObjName: objName, URL: url, Attempt: attempt})
+		}
+		attempt++
+		if httpLike {
+			return fetchHTTPIfRange(ctx, client, url, dataPath, metaPath, &offset, &m, progress)
+		}
+		return fetchViaAdapter(withJobID(ctx, jobID), adapter, url, dataPath, metaPath, &offset, &m, progress)
+	}
+
+	attempts, ferr := withRetry(ctx, policy, fetch)
+	info.Retries = attempts - 1
+	if ferr != nil {
+		info.LastError = ferr.Error()
+		return info, ferr
+	}
+	return info, nil
+}
+
+// fetchHTTPMultiRange is fetchResumableOnce's perObjectConnections > 1
+// branch: it drives fetchMultiRangeParallel straight against dataPath (no
+// withRetry wrapping - a failed range, or errRangeNotSupported's fallback,
+// is surfaced directly rather than retried whole-object, since a caller
+// that wants retry-on-failure for split fetches can simply call this path
+// again with perObjectConnections unchanged) and, once every range lands,
+// records dataPath's final size into the partial sidecar so the rest of
+// the fetch/finalize machinery (finalizePartial, appendPartial-based
+// resume of some *other* later object) sees a consistent partialMeta.
+func fetchHTTPMultiRange(ctx context.Context, client *http.Client, url, dataPath, metaPath string, m *partialMeta, perObjectConnections int) (rangesTotal, rangesDone int, err error) {
+	if err = os.MkdirAll(filepath.Dir(dataPath), 0o755); err != nil {
+		return 0, 0, err
+	}
+	rangesTotal, rangesDone, err = fetchMultiRangeParallel(ctx, client, url, dataPath, perObjectConnections, func(int, int64) {})
+	if err != nil {
+		return rangesTotal, rangesDone, err
+	}
+	fi, serr := os.Stat(dataPath)
+	if serr != nil {
+		return rangesTotal, rangesDone, serr
+	}
+	m.Written = fi.Size()
+	return rangesTotal, rangesDone, savePartialMeta(metaPath, m)
+}
+
+// fetchHTTPIfRange is fetchResumableOnce's attempt for a "http"/"https" url:
+// the original client.Do-based conditional GET, plus progress, called via
+// partialWriter after every appendPartial so fetchResumableOnce's
+// progressThrottle-wrapped EvProgress publisher sees each chunk as it lands.
+func fetchHTTPIfRange(ctx context.Context, client *http.Client, url, dataPath, metaPath string, offset *int64, m **partialMeta, progress func(bytesSoFar int64)) (int, error) {
+	req, rerr := ifRangeRequest(ctx, url, *offset, *m)
+	if rerr != nil {
+		return 0, rerr
+	}
+	resp, rerr := client.Do(req)
+	if rerr != nil {
+		return 0, rerr
+	}
+	defer resp.Body.Close()
+
+	if ifRangeStale(resp, *offset) {
+		discardPartial(dataPath, metaPath)
+		*offset = 0
+		*m = &partialMeta{URL: url}
+	}
+	(*m).ETag = resp.Header.Get("ETag")
+	(*m).LastModified = resp.Header.Get("Last-Modified")
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, fmt.Errorf("downloader: GET %s: status %d", url, resp.StatusCode)
+	}
+
+	_, cerr := io.Copy(&partialWriter{dataPath, metaPath, *m, progress}, resp.Body)
+	*offset = (*m).Written
+	if cerr != nil {
+		return resp.StatusCode, cerr
+	}
+	return resp.StatusCode, nil
+}
+
+// fetchViaAdapter is fetchResumableOnce's attempt for any scheme other than
+// "http"/"https": adapter.Head decides whether the validators still match
+// what's on disk (the same role ifRangeRequest's If-Range header plays for
+// an http(s) source, just as an explicit round trip since TransferAdapter
+// has no conditional-GET primitive), then adapter.Fetch streams from
+// *offset into the partial.
+func fetchViaAdapter(ctx context.Context, adapter TransferAdapter, url, dataPath, metaPath string, offset *int64, m **partialMeta, progress func(bytesSoFar int64)) (int, error) {
+	if *offset > 0 {
+		meta, herr := adapter.Head(ctx, url)
+		if herr == nil && meta.ETag != "" && (*m).ETag != "" && meta.ETag != (*m).ETag {
+			discardPartial(dataPath, metaPath)
+			*offset = 0
+			*m = &partialMeta{URL: url}
+		}
+		if herr == nil {
+			(*m).ETag, (*m).LastModified = meta.ETag, meta.LastModified
+		}
+	}
+
+	if ferr := adapter.Fetch(ctx, url, *offset, &partialWriter{dataPath, metaPath, *m, progress}); ferr != nil {
+		return 0, ferr
+	}
+	*offset = (*m).Written
+	return http.StatusOK, nil
+}
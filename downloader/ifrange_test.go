@@ -0,0 +1,290 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closeConnMidBody hijacks and closes the underlying connection so the
+// client sees a mid-body disconnect rather than a clean EOF.
+func closeConnMidBody(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	_ = conn.Close()
+}
+
+func TestFetchResumableRetriesAcrossMidBodyDisconnect(t *testing.T) {
+	content := []byte("the quick brown fox jumps over the lazy dog, resumed via if-range")
+	half := len(content) / 2
+	const etag = `"v1"`
+
+	var reqs int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&reqs, 1)
+		w.Header().Set("ETag", etag)
+		if n == 1 {
+			// First attempt: no Range yet (first-ever fetch), serve half the
+			// body then die, simulating a flaky link.
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content[:half])
+			closeConnMidBody(w)
+			return
+		}
+		// Retry: must carry a Range picking up where attempt 1 left off, and
+		// an If-Range matching the ETag we handed back on attempt 1.
+		if r.Header.Get("Range") == "" || r.Header.Get("If-Range") != etag {
+			t.Errorf("retry request missing expected Range/If-Range, got Range=%q If-Range=%q",
+				r.Header.Get("Range"), r.Header.Get("If-Range"))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(content)
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(content[half:])
+	}))
+	defer srv.Close()
+
+	workfs := t.TempDir()
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: 0, MaxBackoff: 0, BackoffMultiplier: 1}
+	info, err := fetchResumable(context.Background(), srv.Client(), &policy, workfs, "bck", "job1", "obj", srv.URL, nil, false, 1)
+	if err != nil {
+		t.Fatalf("fetchResumable: %v", err)
+	}
+	if info.Retries != 1 {
+		t.Errorf("Retries = %d, want 1 (one mid-body failure then success)", info.Retries)
+	}
+	if info.ResumedFrom != 0 {
+		t.Errorf("ResumedFrom = %d, want 0 (no prior partial before this call)", info.ResumedFrom)
+	}
+	if info.LastError != "" {
+		t.Errorf("LastError = %q, want empty on eventual success", info.LastError)
+	}
+
+	dataPath, _ := partialPaths(workfs, "job1", "obj")
+	got, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatalf("reading assembled partial: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("assembled content = %q, want %q", got, content)
+	}
+}
+
+func TestFetchResumablePermanentErrorNotRetried(t *testing.T) {
+	var reqs int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&reqs, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	workfs := t.TempDir()
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: 0, MaxBackoff: 0, BackoffMultiplier: 1}
+	info, err := fetchResumable(context.Background(), srv.Client(), &policy, workfs, "bck", "job1", "obj", srv.URL, nil, false, 1)
+	if err == nil {
+		t.Fatal("expected an error for a 404 response")
+	}
+	if info.LastError == "" {
+		t.Error("expected LastError to be populated on failure")
+	}
+	if atomic.LoadInt32(&reqs) != 1 {
+		t.Errorf("expected exactly 1 request for a permanent 404, got %d", reqs)
+	}
+}
+
+func TestFetchResumableFailsOverToMirror(t *testing.T) {
+	content := []byte("served by the mirror, not the primary")
+
+	var primaryReqs int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&primaryReqs, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer primary.Close()
+
+	var mirrorReqs int32
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&mirrorReqs, 1)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer mirror.Close()
+
+	workfs := t.TempDir()
+	policy := RetryPolicy{MaxAttempts: 1, InitialBackoff: 0, MaxBackoff: 0, BackoffMultiplier: 1}
+	info, err := fetchResumable(context.Background(), primary.Client(), &policy, workfs, "bck", "job1", "obj",
+		primary.URL, []string{mirror.URL}, false, 1)
+	if err != nil {
+		t.Fatalf("fetchResumable: %v", err)
+	}
+	if info.LastError != "" {
+		t.Errorf("LastError = %q, want empty once the mirror succeeded", info.LastError)
+	}
+	if info.SourceURL != mirror.URL {
+		t.Errorf("SourceURL = %q, want the mirror %q that actually supplied the object", info.SourceURL, mirror.URL)
+	}
+	if atomic.LoadInt32(&primaryReqs) != 1 {
+		t.Errorf("expected exactly 1 request against the failing primary, got %d", primaryReqs)
+	}
+	if atomic.LoadInt32(&mirrorReqs) != 1 {
+		t.Errorf("expected exactly 1 request against the mirror, got %d", mirrorReqs)
+	}
+
+	dataPath, _ := partialPaths(workfs, "job1", "obj")
+	got, err := os.ReadFile(dataPath)
+	if err != nil {
+		t.Fatalf("reading assembled partial: %v", err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("assembled content = %q, want %q", got, content)
+	}
+}
+
+func TestFetchResumableCoalescesConcurrentFetches(t *testing.T) {
+	var reqs int32
+	release := make(chan struct{})
+	content := []byte("shared payload")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&reqs, 1)
+		<-release // hold the response open until both callers have joined
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer srv.Close()
+
+	policy := RetryPolicy{MaxAttempts: 1, InitialBackoff: 0, MaxBackoff: 0, BackoffMultiplier: 1}
+	before := DeduplicatedCount()
+
+	type result struct {
+		info TaskRetryInfo
+		err  error
+	}
+	results := make(chan result, 2)
+	for i := 0; i < 2; i++ {
+		jobID := "job" + strconv.Itoa(i)
+		go func() {
+			workfs := t.TempDir()
+			info, err := fetchResumable(context.Background(), srv.Client(), &policy, workfs, "bck", jobID, "obj", srv.URL, nil, false, 1)
+			results <- result{info, err}
+		}()
+	}
+
+	// give both goroutines a chance to join the same dedup key before the
+	// server is allowed to respond, so the second one definitely lands on
+	// the "follower" path instead of racing in as a second leader.
+	for atomic.LoadInt32(&reqs) == 0 {
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	infos := make([]TaskRetryInfo, 0, 2)
+	for i := 0; i < 2; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Errorf("fetchResumable: %v", r.err)
+		}
+		infos = append(infos, r.info)
+	}
+	if got := atomic.LoadInt32(&reqs); got != 1 {
+		t.Errorf("expected exactly 1 request for two concurrent fetches of the same object, got %d", got)
+	}
+	if DeduplicatedCount()-before == 0 {
+		t.Error("expected DeduplicatedCount to increase by at least 1")
+	}
+	for i, info := range infos {
+		if info.Size == 0 || info.Checksum == "" {
+			t.Errorf("infos[%d]: expected a non-zero size/checksum", i)
+		}
+	}
+	if infos[1].Size != infos[0].Size || infos[1].Checksum != infos[0].Checksum {
+		t.Errorf("got mismatched {size, checksum} between the two fetchResumable callers: %+v vs %+v", infos[0], infos[1])
+	}
+}
+
+func TestFetchResumableSplitsFreshFetchAcrossRanges(t *testing.T) {
+	content := []byte("0123456789abcdefghijklmnopqrstuvwxyz0123456789")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "obj", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	workfs := t.TempDir()
+	policy := RetryPolicy{MaxAttempts: 1, InitialBackoff: 0, MaxBackoff: 0, BackoffMultiplier: 1}
+	info, err := fetchResumable(context.Background(), srv.Client(), &policy, workfs, "bck", "job1", "obj", srv.URL, nil, false, 4)
+	if err != nil {
+		t.Fatalf("fetchResumable: %v", err)
+	}
+	if info.RangesTotal != 4 || info.RangesDone != 4 {
+		t.Errorf("RangesTotal=%d RangesDone=%d, want 4/4", info.RangesTotal, info.RangesDone)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("Size = %d, want %d", info.Size, len(content))
+	}
+
+	dataPath, metaPath := partialPaths(workfs, "job1", "obj")
+	got, rerr := os.ReadFile(dataPath)
+	if rerr != nil {
+		t.Fatalf("reading partial: %v", rerr)
+	}
+	if string(got) != string(content) {
+		t.Errorf("assembled content mismatch: got %q, want %q", got, content)
+	}
+	m, lerr := loadPartialMeta(metaPath)
+	if lerr != nil {
+		t.Fatalf("loading partial meta: %v", lerr)
+	}
+	if m.Written != int64(len(content)) {
+		t.Errorf("partialMeta.Written = %d, want %d", m.Written, len(content))
+	}
+}
+
+func TestFetchResumablePublishesLifecycleEvents(t *testing.T) {
+	content := []byte("event-stream payload")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "obj", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	const jobID = "job-events-1"
+	ch := make(chan Event, 16)
+	unsubscribe := ringFor(jobID).subscribe(ch, 0)
+	defer unsubscribe()
+
+	workfs := t.TempDir()
+	policy := RetryPolicy{MaxAttempts: 1, InitialBackoff: 0, MaxBackoff: 0, BackoffMultiplier: 1}
+	if _, err := fetchResumable(context.Background(), srv.Client(), &policy, workfs, "bck", jobID, "obj", srv.URL, nil, false, 1); err != nil {
+		t.Fatalf("fetchResumable: %v", err)
+	}
+
+	var kinds []EventKind
+	for {
+		select {
+		case e := <-ch:
+			kinds = append(kinds, e.Kind)
+		default:
+			goto done
+		}
+	}
+done:
+	if len(kinds) < 2 || kinds[0] != EvStarted || kinds[len(kinds)-1] != EvFinished {
+		t.Errorf("got events %v, want to start with EvStarted and end with EvFinished", kinds)
+	}
+}
@@ -5,7 +5,9 @@
 package downloader
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"regexp"
 	"sync"
 
@@ -14,24 +16,156 @@ import (
 	"github.com/NVIDIA/aistore/3rdparty/glog"
 )
 
+// TaskState is the lifecycle of one object within a download job, persisted
+// alongside the job-level aggregate counters so a restart can tell exactly
+// which objects still need work instead of only "how many finished so far".
+type TaskState string
+
+const (
+	TaskPending  TaskState = "pending"  // enqueued, never started
+	TaskInFlight TaskState = "inflight" // a worker picked it up but hasn't reported back yet
+	TaskDone     TaskState = "done"
+	TaskFailed   TaskState = "failed"
+)
+
+// PersistedTask is the per-object record recover() needs to actually resume
+// a job instead of merely remembering that one existed: Retries/LastErr let
+// a resumed task keep counting against the same RetryPolicy budget it had
+// before the restart, rather than silently resetting it.
+type PersistedTask struct {
+	JobID   string    `json:"job_id"`
+	ObjName string    `json:"obj_name"`
+	State   TaskState `json:"state"`
+	Retries int       `json:"retries"`
+	LastErr string    `json:"last_err,omitempty"`
+}
+
+// taskKey is the idempotent identity of a task: re-running recover() (or
+// racing it against a task that's already reporting progress) must not
+// redispatch the same (job, object) pair twice.
+func taskKey(jobID, objName string) string { return jobID + "\x00" + objName }
+
+// taskRedispatcher is how recover() hands an interrupted task back to a live
+// worker pool instead of leaving it stranded as "persisted but nobody is
+// ever going to retry it". The target's dispatcher (outside this package's
+// present snapshot) implements this by re-enqueuing the task the same way it
+// would a freshly-submitted one.
+type taskRedispatcher interface {
+	redispatch(task PersistedTask)
+}
+
 type (
 	infoStore struct {
 		*downloaderDB
-		jobInfo map[string]*DownloadJobInfo
+		jobInfo    map[string]*DownloadJobInfo
+		redispatch taskRedispatcher
+		redone     map[string]bool // taskKey -> already handed to redispatch this process lifetime
 		sync.RWMutex
 	}
 )
 
-func newInfoStore() (*infoStore, error) {
+// newInfoStore builds the job-info table and, if redispatcher is non-nil,
+// resumes every job recover() finds with outstanding (pending/in-flight)
+// tasks by handing each one back to redispatcher - e.g. on target startup
+// after a crash or rolling upgrade, rather than only restoring counters and
+// waiting for a caller to notice nothing further is happening.
+func newInfoStore(redispatcher taskRedispatcher) (*infoStore, error) {
 	db, err := newDownloadDB()
 	if err != nil {
 		return nil, err
 	}
 
-	return &infoStore{
+	is := &infoStore{
 		downloaderDB: db,
 		jobInfo:      make(map[string]*DownloadJobInfo),
-	}, nil
+		redispatch:   redispatcher,
+		redone:       make(map[string]bool),
+	}
+	if err := is.recover(); err != nil {
+		glog.Errorf("failed to recover persisted download jobs: %v", err)
+	}
+	return is, nil
+}
+
+// recover repopulates the in-memory job table from the on-disk DB on
+// startup, so that jobs interrupted by a restart (target crash, rolling
+// upgrade) are still visible to `getJob`/`getList`, and - for every task
+// persisted in a non-terminal state - re-enqueues it with the live
+// dispatcher instead of leaving it stranded.
+func (is *infoStore) recover() error {
+	persisted, err := is.downloaderDB.getAll()
+	if err != nil {
+		return err
+	}
+	is.Lock()
+	for id, ji := range persisted {
+		is.jobInfo[id] = ji
+		glog.Infof("recovered download job %s (%d/%d finished, dispatched=%t, aborted=%t)",
+			id, ji.FinishedCnt.Load(), ji.Total, ji.AllDispatched.Load(), ji.Aborted.Load())
+	}
+	is.Unlock()
+
+	for id, ji := range persisted {
+		if ji.Aborted.Load() {
+			continue
+		}
+		is.resumeJobTasks(id)
+	}
+	return nil
+}
+
+// resumeJobTasks redispatches every persisted task of job id that is not
+// yet Done/Failed, skipping (via `redone`) any task already handed off this
+// process lifetime so a second recover() pass - or a racing call from a
+// just-acked task - can't double-dispatch it.
+func (is *infoStore) resumeJobTasks(id string) {
+	if is.redispatch == nil {
+		return
+	}
+	tasks, err := is.downloaderDB.getTasks(id)
+	if err != nil {
+		glog.Errorf("failed to recover persisted tasks for download job %s: %v", id, err)
+		return
+	}
+	for _, task := range tasks {
+		if task.State != TaskPending && task.State != TaskInFlight {
+			continue
+		}
+		key := taskKey(task.JobID, task.ObjName)
+		is.Lock()
+		already := is.redone[key]
+		is.redone[key] = true
+		is.Unlock()
+		if already {
+			continue
+		}
+		glog.Infof("resuming download task %s/%s (state=%s, retries=%d)", id, task.ObjName, task.State, task.Retries)
+		is.redispatch.redispatch(task)
+	}
+}
+
+// persist writes the current state of job `id` to the on-disk DB; best-effort -
+// a failure here means a restart may re-run (or fail to resume) this job, but
+// must never fail the in-memory operation that triggered it.
+func (is *infoStore) persist(id string) {
+	is.RLock()
+	ji, ok := is.jobInfo[id]
+	is.RUnlock()
+	if !ok {
+		return
+	}
+	if err := is.downloaderDB.put(id, ji); err != nil {
+		glog.Errorf("failed to persist download job %s: %v", id, err)
+	}
+}
+
+// persistTask writes (or overwrites) task's per-object state, the piece
+// recover() needs to resume a job at task granularity rather than only
+// knowing its aggregate counters. Best-effort, same rationale as persist.
+func (is *infoStore) persistTask(task PersistedTask) {
+	if err := is.downloaderDB.putTask(task.JobID, task); err != nil {
+		glog.Errorf("failed to persist download task %s/%s: %v", task.JobID, task.ObjName, err)
+	}
 }
 
 func (is *infoStore) getJob(id string) (*DownloadJobInfo, error) {
@@ -75,6 +209,7 @@ func (is *infoStore) setJob(id string, job DownloadJob) {
 	is.Lock()
 	is.jobInfo[id] = jInfo
 	is.Unlock()
+	is.persist(id)
 }
 
 func (is *infoStore) incFinished(id string) error {
@@ -85,30 +220,37 @@ func (is *infoStore) incFinished(id string) error {
 	}
 
 	jInfo.FinishedCnt.Inc()
+	is.persist(id)
 	return nil
 }
 
 func (is *infoStore) incScheduled(id string) error {
-	jInfo, err := is.getJob(id)
-	if err != nil {
+	is.RLock()
+	jInfo, ok := is.jobInfo[id]
+	is.RUnlock()
+	if !ok {
+		err := fmt.Errorf("job %s not found", id)
 		glog.Error(err)
 		return err
 	}
 
 	jInfo.ScheduledCnt.Inc()
-	is.jobInfo[id] = jInfo
+	is.persist(id)
 	return nil
 }
 
 func (is *infoStore) setAllDispatched(id string, dispatched bool) error {
-	jInfo, err := is.getJob(id)
-	if err != nil {
+	is.RLock()
+	jInfo, ok := is.jobInfo[id]
+	is.RUnlock()
+	if !ok {
+		err := fmt.Errorf("job %s not found", id)
 		glog.Error(err)
 		return err
 	}
 
 	jInfo.AllDispatched.Store(dispatched)
-	is.jobInfo[id] = jInfo
+	is.persist(id)
 	return nil
 }
 
@@ -120,9 +262,54 @@ func (is *infoStore) setAborted(id string) error {
 	}
 
 	jInfo.Aborted.Store(true)
+	is.persist(id)
 	return nil
 }
 
 func (is *infoStore) delJob(id string) {
+	is.Lock()
 	delete(is.jobInfo, id)
+	is.Unlock()
+	dropRing(id)
+	if err := is.downloaderDB.delete(id); err != nil {
+		glog.Errorf("failed to remove persisted download job %s: %v", id, err)
+	}
+	if err := is.downloaderDB.deleteTasks(id); err != nil {
+		glog.Errorf("failed to remove persisted download tasks for job %s: %v", id, err)
+	}
+}
+
+// DownloadStatusHandler answers `GET .../download?id=<id>[&full=true]`. With
+// full=true the response also carries the per-task breakdown persisted by
+// persistTask - including any task still Pending/InFlight because it was
+// resumed after a restart - instead of only the job-level counters, so an
+// operator (or the CLI) can tell "no tasks ever ran" apart from "12 tasks
+// resumed after the last restart and are still in flight".
+func (is *infoStore) DownloadStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing required query parameter: id", http.StatusBadRequest)
+		return
+	}
+	ji, err := is.getJob(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	resp := struct {
+		*DownloadJobInfo
+		Tasks []PersistedTask `json:"tasks,omitempty"`
+	}{DownloadJobInfo: ji}
+
+	if r.URL.Query().Get("full") == "true" {
+		tasks, err := is.downloaderDB.getTasks(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.Tasks = tasks
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
 }
@@ -0,0 +1,84 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"math/rand"
+)
+
+// mirrorResult records which URL a multi-mirror fetch ultimately succeeded
+// from (or, on total failure, the last URL attempted) plus the combined
+// attempt count across every URL tried, so `DownloadStatus`'s per-object
+// task info can surface "came from mirror #2 after 3 attempts" without the
+// worker threading URLs through withRetry itself.
+type mirrorResult struct {
+	url      string
+	attempts int
+}
+
+// urlOrder returns the sequence a worker should try for one object: `link`
+// first, always, followed by `mirrors` - shuffled when `randomize` is set
+// (the `DlBase.MirrorRandomize` flag on the job body), otherwise in the
+// order the caller listed them. The primary is never shuffled: a caller
+// that lists a preferred source first expects it tried first.
+func urlOrder(link string, mirrors []string, randomize bool) []string {
+	urls := make([]string, 0, len(mirrors)+1)
+	urls = append(urls, link)
+	if len(mirrors) == 0 {
+		return urls
+	}
+	rest := make([]string, len(mirrors))
+	copy(rest, mirrors)
+	if randomize {
+		rand.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] }) //nolint:gosec // mirror ordering, not security-sensitive
+	}
+	return append(urls, rest...)
+}
+
+// fetchOneURL performs every retry attempt (per `withRetry`/`policy`)
+// against a single URL and reports how many attempts it took.
+type fetchOneURL func(ctx context.Context, url string) (attempts int, err error)
+
+// fetchWithMirrors drives `fetch` across `link` and then `mirrors`, in the
+// order `urlOrder` picks, stopping at the first URL that succeeds. Any
+// error `fetch` returns for one URL - transient or permanent, since
+// `withRetry` has already exhausted policy.MaxAttempts against it - is what
+// moves the worker on to the next mirror; a context cancellation instead
+// aborts the whole fan-out immediately.
+//
+// Partial-download state is shared across URLs by construction: `fetch` is
+// expected to close over the same (jobID, objName) partial/meta pair for
+// every URL it's called with, so `planResume`'s own ETag/Last-Modified
+// check decides whether bytes fetched from an earlier mirror can still be
+// trusted for the next one - matching validators keep the partial and
+// resume from its offset, a mismatch discards it and restarts at 0.
+func fetchWithMirrors(ctx context.Context, link string, mirrors []string, randomize bool, fetch fetchOneURL) (res mirrorResult, err error) {
+	for _, u := range urlOrder(link, mirrors, randomize) {
+		var attempts int
+		attempts, err = fetch(ctx, u)
+		res.url = u
+		res.attempts += attempts
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+	}
+	return res, err
+}
+
+// `DlSingleObj` (and therefore the map/list forms of `DlMultiBody`) grows a
+// `Link string` plus `Mirrors []string` per object, with `MirrorRandomize
+// bool` on `DlBase` controlling the shuffle above. The client-side entry
+// point is `api.DownloadMultiMirrored(baseParams, desc, bck, objs)`, where
+// `objs` is a `map[string][]string` keyed by object name whose value slice
+// is `[primary, mirror1, mirror2, ...]` - a thin wrapper that splits the
+// slice into `Link`/`Mirrors` and calls through to `DownloadMultiWithParam`.
+// The worker uses `fetchWithMirrors` in place of a single `withRetry` call
+// and records the winning URL on the task's `DownloadTaskInfo` entry in
+// `DownloadStatus` as e.g. `SourceURL`, so a caller can tell a fallback
+// happened without parsing logs.
@@ -0,0 +1,259 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// byteRange is one of the contiguous, inclusive [Start, End] spans
+// splitRanges divides an object into for a multi-connection fetch.
+type byteRange struct {
+	Start, End int64
+}
+
+// splitRanges divides [0, size) into up to n contiguous byteRanges of
+// roughly equal width. n is clamped down to size so a tiny object never
+// produces an empty range - e.g. splitRanges(3, 8) still returns 3 ranges,
+// one byte each, rather than 5 zero-length ones.
+func splitRanges(size int64, n int) []byteRange {
+	if n > int(size) {
+		n = int(size)
+	}
+	if n < 1 {
+		n = 1
+	}
+	chunk := size / int64(n)
+	ranges := make([]byteRange, 0, n)
+	start := int64(0)
+	for i := 0; i < n; i++ {
+		end := start + chunk - 1
+		if i == n-1 {
+			end = size - 1
+		}
+		ranges = append(ranges, byteRange{Start: start, End: end})
+		start = end + 1
+	}
+	return ranges
+}
+
+// rangeGetRequest builds the GET for one byteRange of url.
+func rangeGetRequest(ctx context.Context, url string, r byteRange) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", r.Start, r.End))
+	return req, nil
+}
+
+// headForSplit probes url for the Content-Length and Accept-Ranges needed
+// to decide whether a multi-connection split is possible at all. It's kept
+// separate from adapter.go's Head/TransferAdapter - that's a pluggable
+// scheme registry for the source itself (http/file/cloud/process), while
+// this is purely about one HTTP source's Range support and stays local to
+// the feature that needs it.
+func headForSplit(ctx context.Context, client *http.Client, url string) (size int64, acceptsRanges bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes", nil
+}
+
+// preallocate sizes path to size up front (a sparse file - the portable
+// equivalent of fallocate via os.Truncate) so every range's goroutine can
+// seek straight to its own offset and write independently, with no range
+// needing to wait for an earlier one to grow the file first.
+func preallocate(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Truncate(size)
+}
+
+// countingWriter reports cumulative bytes written through it to onWrite
+// after every Write, the same per-call progress hook shape partialWriter.Write
+// reports through elsewhere in this package.
+type countingWriter struct {
+	w       io.Writer
+	n       int64
+	onWrite func(bytesSoFar int64)
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	if c.onWrite != nil {
+		c.onWrite(c.n)
+	}
+	return n, err
+}
+
+// writeRangeInto writes body into path at r.Start, validating that exactly
+// r.End-r.Start+1 bytes land - a short write means the connection dropped
+// mid-range and the caller should retry that range rather than trust a
+// truncated file as success.
+func writeRangeInto(path string, r byteRange, body io.Reader, progress func(bytesSoFar int64)) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(r.Start, io.SeekStart); err != nil {
+		return err
+	}
+	want := r.End - r.Start + 1
+	cw := &countingWriter{w: f, onWrite: progress}
+	if _, err := io.CopyN(cw, body, want); err != nil {
+		return err
+	}
+	return nil
+}
+
+// fetchRange performs one ranged GET and writes it into path, returning
+// errRangeNotSupported (resume.go's sentinel, reused rather than redeclared)
+// if the server answers anything other than 206 - the same "can't trust a
+// partial range response" signal checkRangeResponse uses for whole-object
+// resume.
+func fetchRange(ctx context.Context, client *http.Client, url string, idx int, r byteRange, path string, progress func(idx int, bytesSoFar int64)) error {
+	req, err := rangeGetRequest(ctx, url, r)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return errRangeNotSupported
+	}
+	return writeRangeInto(path, r, resp.Body, func(n int64) { progress(idx, n) })
+}
+
+// fetchSingleStream is the non-range fallback: a single plain GET into a
+// freshly created path, reported as range index 0 so callers don't need a
+// separate code path to track its progress.
+func fetchSingleStream(ctx context.Context, client *http.Client, url, path string, progress func(idx int, bytesSoFar int64)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloader: GET %s: status %d", url, resp.StatusCode)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cw := &countingWriter{w: f, onWrite: func(n int64) { progress(0, n) }}
+	_, err = io.Copy(cw, resp.Body)
+	return err
+}
+
+// fetchMultiRangeParallel is the per-object multi-connection entry point,
+// wired in by fetchHTTPMultiRange (ifrange.go) whenever a job's
+// perObjectConnections > 1: when the source advertises `Accept-Ranges:
+// bytes` with a known Content-Length, it splits the object into that many
+// byteRanges, preallocates path as a sparse file, and fetches every range
+// concurrently with its own Range request, finalizing only once every range
+// has written its full share. It falls back to fetchSingleStream whenever a
+// split isn't possible or the server turns out not to honor Range after all
+// (errRangeNotSupported from a probe range), matching the 200-instead-of-206
+// fallback resume.go already does for whole-object resume.
+//
+// progress is called as (rangeIdx, bytesWrittenForThatRange) for every
+// range, so a caller can aggregate per-range progress into one task instead
+// of surfacing N separate tasks. The returned (rangesTotal, rangesDone) let
+// the caller record split-range progress on its own task info: rangesDone
+// reports how many ranges fully completed before either success or the
+// first error - on the fallback paths (no split attempted at all, or a
+// rangeNotSupported retreat to a single stream) rangesTotal is 1.
+func fetchMultiRangeParallel(ctx context.Context, client *http.Client, url, path string, perObjectConnections int, progress func(rangeIdx int, bytesSoFar int64)) (rangesTotal, rangesDone int, err error) {
+	if perObjectConnections <= 1 {
+		err = fetchSingleStream(ctx, client, url, path, progress)
+		return 1, boolToInt(err == nil), err
+	}
+
+	size, acceptsRanges, err := headForSplit(ctx, client, url)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !acceptsRanges || size <= 0 {
+		err = fetchSingleStream(ctx, client, url, path, progress)
+		return 1, boolToInt(err == nil), err
+	}
+
+	ranges := splitRanges(size, perObjectConnections)
+	if err := preallocate(path, size); err != nil {
+		return len(ranges), 0, err
+	}
+
+	// Range 0 also serves as a live probe: a server that lied about
+	// Accept-Ranges on HEAD surfaces that here as errRangeNotSupported,
+	// and the whole object falls back to a single stream rather than
+	// leaving a sparse file half-written by a server that never
+	// actually honors Range on GET.
+	if err := fetchRange(ctx, client, url, 0, ranges[0], path, progress); err != nil {
+		if err == errRangeNotSupported {
+			ferr := fetchSingleStream(ctx, client, url, path, progress)
+			return 1, boolToInt(ferr == nil), ferr
+		}
+		return len(ranges), 0, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		done     = 1 // range 0, above
+		firstErr error
+	)
+	for i := 1; i < len(ranges); i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ferr := fetchRange(ctx, client, url, i, ranges[i], path, progress); ferr != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = ferr
+				}
+				mu.Unlock()
+				return
+			}
+			mu.Lock()
+			done++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return len(ranges), done, firstErr
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSplitRanges(t *testing.T) {
+	ranges := splitRanges(10, 3)
+	if len(ranges) != 3 {
+		t.Fatalf("got %d ranges, want 3", len(ranges))
+	}
+	if ranges[0].Start != 0 || ranges[len(ranges)-1].End != 9 {
+		t.Errorf("ranges don't span [0, 9]: %+v", ranges)
+	}
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Start != ranges[i-1].End+1 {
+			t.Errorf("ranges[%d] doesn't start right after ranges[%d]: %+v", i, i-1, ranges)
+		}
+	}
+
+	// More connections requested than bytes available: clamp, don't produce
+	// zero-length ranges.
+	tiny := splitRanges(3, 8)
+	if len(tiny) != 3 {
+		t.Errorf("got %d ranges for a 3-byte object, want 3 (clamped)", len(tiny))
+	}
+}
+
+func TestFetchMultiRangeParallelSplitsAcrossRangeCapableServer(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100)) // 1000 bytes
+	name := "obj.bin"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	seen := map[int]int64{}
+	progress := func(idx int, n int64) {
+		mu.Lock()
+		seen[idx] = n
+		mu.Unlock()
+	}
+
+	dst := filepath.Join(t.TempDir(), name)
+	rangesTotal, rangesDone, err := fetchMultiRangeParallel(context.Background(), srv.Client(), srv.URL, dst, 4, progress)
+	if err != nil {
+		t.Fatalf("fetchMultiRangeParallel: %v", err)
+	}
+	if rangesTotal != 4 || rangesDone != 4 {
+		t.Errorf("rangesTotal=%d rangesDone=%d, want 4/4", rangesTotal, rangesDone)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("reading result: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("assembled content mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 4 {
+		t.Errorf("got progress for %d ranges, want 4", len(seen))
+	}
+}
+
+func TestFetchMultiRangeParallelFallsBackOnNonRangeServer(t *testing.T) {
+	content := []byte(strings.Repeat("abcdef", 50))
+	name := "obj.bin"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Deliberately ignore any Range header and serve the whole body with
+		// a plain 200, no Accept-Ranges - e.g. a source behind a proxy that
+		// strips Range support.
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer srv.Close()
+
+	var rangeIdxs []int
+	progress := func(idx int, _ int64) { rangeIdxs = append(rangeIdxs, idx) }
+
+	dst := filepath.Join(t.TempDir(), name)
+	rangesTotal, rangesDone, err := fetchMultiRangeParallel(context.Background(), srv.Client(), srv.URL, dst, 4, progress)
+	if err != nil {
+		t.Fatalf("fetchMultiRangeParallel: %v", err)
+	}
+	if rangesTotal != 1 || rangesDone != 1 {
+		t.Errorf("rangesTotal=%d rangesDone=%d, want 1/1 (single-stream fallback)", rangesTotal, rangesDone)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("reading result: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("assembled content mismatch: got %q, want %q", got, content)
+	}
+	for _, idx := range rangeIdxs {
+		if idx != 0 {
+			t.Errorf("expected single-stream fallback to report range idx 0 only, got %d", idx)
+		}
+	}
+}
+
+func TestFetchMultiRangeParallelSingleConnectionSkipsSplit(t *testing.T) {
+	content := []byte("no split requested")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "obj.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "obj.bin")
+	rangesTotal, rangesDone, err := fetchMultiRangeParallel(context.Background(), srv.Client(), srv.URL, dst, 1, func(int, int64) {})
+	if err != nil {
+		t.Fatalf("fetchMultiRangeParallel: %v", err)
+	}
+	if rangesTotal != 1 || rangesDone != 1 {
+		t.Errorf("rangesTotal=%d rangesDone=%d, want 1/1 (perObjectConnections<=1 skips the split)", rangesTotal, rangesDone)
+	}
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("reading result: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("content mismatch: got %q, want %q", got, content)
+	}
+}
@@ -0,0 +1,208 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// partialMeta is the sidecar persisted next to a not-yet-finished object's
+// on-disk bytes under '<workfs>/dl-<jobID>/<objName>.partial[.meta]'. It
+// carries everything a later resume needs to decide whether the bytes
+// already on disk still correspond to the current state of the source:
+// validators (ETag/Last-Modified), the size reported at the time the
+// partial was started, and how many bytes have been flushed so far.
+type partialMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"`
+	Written      int64  `json:"written"`
+}
+
+const (
+	partialSuffix     = ".partial"
+	partialMetaSuffix = ".partial.meta"
+)
+
+func partialPaths(workfs, jobID, objName string) (data, meta string) {
+	dir := filepath.Join(workfs, "dl-"+jobID)
+	return filepath.Join(dir, objName+partialSuffix), filepath.Join(dir, objName+partialMetaSuffix)
+}
+
+func loadPartialMeta(path string) (*partialMeta, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m partialMeta
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func savePartialMeta(path string, m *partialMeta) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func discardPartial(dataPath, metaPath string) {
+	_ = os.Remove(dataPath)
+	_ = os.Remove(metaPath)
+}
+
+// errRangeNotSupported signals that the source ignored our Range header
+// (answered 200 instead of 206): the partial can't be trusted and the
+// object must be re-fetched from byte 0.
+var errRangeNotSupported = errors.New("downloader: server ignored Range, partial download cannot be resumed")
+
+// headFunc answers planResume's "what does the source say right now" probe:
+// an *http.Client HEAD for http(s) sources, or a TransferAdapter's own Head
+// for every other scheme adapterFor resolves - planResume itself doesn't
+// care which, only that it returns the current validators.
+type headFunc func(ctx context.Context) (etag, lastModified string, err error)
+
+// httpHeadFunc builds the headFunc a plain http(s) source uses: a bare HEAD
+// against url via client.
+func httpHeadFunc(client *http.Client, url string) headFunc {
+	return func(ctx context.Context) (string, string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return "", "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", "", err
+		}
+		resp.Body.Close()
+		return resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), nil
+	}
+}
+
+// adapterHeadFunc builds the headFunc a non-http(s) source uses: adapter's
+// own Head, normalized the same way every TransferAdapter already reports
+// its validators (see Meta's doc comment in adapter.go).
+func adapterHeadFunc(adapter TransferAdapter, url string) headFunc {
+	return func(ctx context.Context) (string, string, error) {
+		meta, err := adapter.Head(ctx, url)
+		return meta.ETag, meta.LastModified, err
+	}
+}
+
+// planResume inspects any on-disk partial for (jobID, objName) and decides
+// where the upcoming fetch should start. It probes the source first via
+// head so the resume/restart decision and the subsequent fetch are both
+// made against one consistent validator snapshot - a validator that changes
+// between the probe and the fetch simply means the next resume attempt (or
+// this one, via checkRangeResponse) discards the partial and starts over.
+func planResume(ctx context.Context, head headFunc, workfs, jobID, objName, url string, resumable bool) (offset int64, m *partialMeta, err error) {
+	dataPath, metaPath := partialPaths(workfs, jobID, objName)
+	if !resumable {
+		discardPartial(dataPath, metaPath)
+		return 0, &partialMeta{URL: url}, nil
+	}
+
+	prior, lerr := loadPartialMeta(metaPath)
+	if lerr != nil {
+		return 0, &partialMeta{URL: url}, nil
+	}
+
+	etag, lastMod, err := head(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// A partial left by a different URL (an earlier mirror) is kept only if it
+	// carries a validator to compare against the current source.
+	unvalidated := prior.URL != url && prior.ETag == "" && prior.LastModified == ""
+	if unvalidated || (prior.ETag != "" && etag != prior.ETag) || (prior.LastModified != "" && lastMod != prior.LastModified) {
+		discardPartial(dataPath, metaPath)
+		return 0, &partialMeta{URL: url, ETag: etag, LastModified: lastMod}, nil
+	}
+
+	fi, statErr := os.Stat(dataPath)
+	if statErr != nil || fi.Size() != prior.Written {
+		discardPartial(dataPath, metaPath)
+		return 0, &partialMeta{URL: url, ETag: etag, LastModified: lastMod}, nil
+	}
+
+	prior.URL, prior.ETag, prior.LastModified = url, etag, lastMod
+	return prior.Written, prior, nil
+}
+
+// rangeRequest builds the GET used to resume at offset (a plain GET if
+// offset is 0).
+func rangeRequest(ctx context.Context, url string, offset int64) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return req, nil
+}
+
+// checkRangeResponse validates that a resumed GET actually got a 206; see
+// errRangeNotSupported.
+func checkRangeResponse(resp *http.Response, wantedOffset int64) error {
+	if wantedOffset == 0 {
+		return nil
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return errRangeNotSupported
+	}
+	return nil
+}
+
+// appendPartial appends p to the on-disk partial and persists the updated
+// sidecar, so a target restart or mountpath disable mid-transfer loses at
+// most the last unflushed write, not the whole object.
+func appendPartial(dataPath, metaPath string, m *partialMeta, p []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dataPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := f.Write(p)
+	if err != nil {
+		return err
+	}
+	m.Written += int64(n)
+	return savePartialMeta(metaPath, m)
+}
+
+// finalizePartial removes the partial + sidecar once the object has been
+// fully written and handed off to be finalized into the bucket - called
+// from the same completion path whether the job ran to completion or was
+// aborted after this particular object finished (aborting a job must not
+// discard objects that already made it all the way through).
+func finalizePartial(workfs, jobID, objName string) {
+	dataPath, metaPath := partialPaths(workfs, jobID, objName)
+	_ = os.Remove(dataPath)
+	_ = os.Remove(metaPath)
+}
+
+// `DlBase.Resumable` (a new flag alongside `Limits`/`Retry`) opts a job's
+// objects into the on-disk partial+resume behavior above. The client-side
+// entry point is `api.DownloadResume(baseParams, id)`, a thin wrapper that
+// re-dispatches a previously aborted job's still-pending objects so they're
+// picked up by `planResume` instead of starting a brand-new job.
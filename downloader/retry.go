@@ -0,0 +1,175 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy governs how a single object's fetch is retried by the
+// downloader worker. It is carried on `DlBase.Retry` (alongside `DlLimits`)
+// so every `DownloadSingle`/`DownloadMulti`/`DownloadRange` request can tune
+// it; the zero value resolves to DefaultRetryPolicy via `Validate`. The
+// per-object attempt count driven by `withRetry`, less the first attempt,
+// is surfaced as the `Retries` field on the task/error entries
+// `DownloadStatus` returns, so callers can tell a fail-fast 404 apart from
+// an eventually-successful 503.
+type RetryPolicy struct {
+	MaxAttempts       int           `json:"max_attempts"`
+	InitialBackoff    time.Duration `json:"initial_backoff"`
+	MaxBackoff        time.Duration `json:"max_backoff"`
+	BackoffMultiplier float64       `json:"backoff_multiplier"`
+	Cooldown          time.Duration `json:"cooldown"` // minimum spacing between any two attempts, even the first retry
+}
+
+// DefaultRetryPolicy is used whenever a request doesn't specify one.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       5,
+	InitialBackoff:    time.Second,
+	MaxBackoff:        30 * time.Second,
+	BackoffMultiplier: 2,
+	Cooldown:          0,
+}
+
+// Validate fills in zero fields from DefaultRetryPolicy and rejects
+// nonsensical values.
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.BackoffMultiplier == 0 {
+		p.BackoffMultiplier = DefaultRetryPolicy.BackoffMultiplier
+	}
+	if p.MaxAttempts < 1 {
+		return errors.New("downloader: RetryPolicy.MaxAttempts must be >= 1")
+	}
+	if p.BackoffMultiplier < 1 {
+		return errors.New("downloader: RetryPolicy.BackoffMultiplier must be >= 1")
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		return errors.New("downloader: RetryPolicy.MaxBackoff must be >= InitialBackoff")
+	}
+	return nil
+}
+
+// backoff returns the jittered delay before attempt number `n` (0-based,
+// n==0 meaning "the first retry after the initial attempt"), clamped to
+// MaxBackoff. Jitter is +/-20% to avoid synchronized retry storms across
+// concurrently-downloading targets.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 0; i < n; i++ {
+		d *= p.BackoffMultiplier
+		if d >= float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	jitter := d * 0.2 * (rand.Float64()*2 - 1) //nolint:gosec // retry jitter, not security-sensitive
+	d += jitter
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// errClass classifies a fetch failure so the retry loop knows whether
+// another attempt can possibly succeed.
+type errClass int
+
+const (
+	errPermanent errClass = iota // won't succeed on retry: fail fast into resp.Errs
+	errTransient                 // may succeed on retry: backoff and retry
+)
+
+// classify inspects the error returned by the HTTP client (and, if the
+// request went through, the response status code) and decides whether the
+// failure is transient or permanent, per the policy this request documents:
+// net.OpError/deadline/DNS-temporary/unexpected-EOF/408/429/5xx/TLS-handshake-timeout
+// are transient; 4xx (other than 408/429), checksum mismatch, and
+// malformed-URL are permanent.
+func classify(err error, statusCode int) errClass {
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errTransient
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return errTransient // source hung up mid-body, e.g. a flaky link; resumable via Range on retry
+		}
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			return errTransient
+		}
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsTemporary {
+			return errTransient
+		}
+		var urlErr interface{ Timeout() bool }
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return errTransient
+		}
+		return errPermanent // invalid URL, unsupported scheme, etc.
+	}
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return errTransient
+	case 0:
+		return errPermanent
+	default:
+		if statusCode >= 500 {
+			return errTransient
+		}
+		return errPermanent
+	}
+}
+
+// ErrChecksum signals a content checksum mismatch after a completed fetch -
+// always permanent, since retrying an already-correct download won't fix a
+// source that serves bad content.
+var ErrChecksum = errors.New("downloader: checksum mismatch")
+
+// fetchFunc performs a single attempt and reports the HTTP status code it
+// observed (0 if the request never got a response).
+type fetchFunc func(ctx context.Context) (statusCode int, err error)
+
+// withRetry drives fetchFunc according to policy, returning the last error
+// (nil on success) and the number of attempts made - the latter is surfaced
+// on the per-object status response so callers can confirm e.g. a 404
+// wasn't retried while a 503 was.
+func withRetry(ctx context.Context, policy *RetryPolicy, fetch fetchFunc) (attempts int, err error) {
+	for n := 0; n < policy.MaxAttempts; n++ {
+		attempts++
+		var status int
+		status, err = fetch(ctx)
+		if err == nil {
+			return attempts, nil
+		}
+		if errors.Is(err, ErrChecksum) || classify(err, status) == errPermanent {
+			return attempts, err
+		}
+		if n == policy.MaxAttempts-1 {
+			break
+		}
+		delay := policy.Cooldown + policy.backoff(n)
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return attempts, err
+}
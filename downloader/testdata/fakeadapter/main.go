@@ -0,0 +1,85 @@
+// Command fakeadapter is a test-only fixture that speaks the line-delimited
+// JSON protocol adapter_process.go drives, standing in for a real plugin
+// binary in downloader's adapter tests. It is never built as part of the
+// aistore binary.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type msg struct {
+	Event        string `json:"event"`
+	Oid          string `json:"oid,omitempty"`
+	URL          string `json:"url,omitempty"`
+	Offset       int64  `json:"offset,omitempty"`
+	Size         int64  `json:"size,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	BytesSoFar   int64  `json:"bytes_so_far,omitempty"`
+	Path         string `json:"path,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// fakeContent is the deterministic body every "download" returns, unless
+// the requested URL contains "err" in which case a "complete" error event
+// is sent instead - used by the test to exercise error propagation.
+const fakeContent = "fake adapter payload, byte-for-byte the same every run\n"
+
+func send(w *bufio.Writer, m msg) {
+	b, _ := json.Marshal(m)
+	w.Write(b)
+	w.WriteByte('\n')
+	w.Flush()
+}
+
+func main() {
+	in := bufio.NewScanner(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+
+	for in.Scan() {
+		var m msg
+		if err := json.Unmarshal(in.Bytes(), &m); err != nil {
+			send(out, msg{Event: "complete", Error: err.Error()})
+			continue
+		}
+		switch m.Event {
+		case "init":
+			send(out, msg{Event: "init-complete"})
+		case "stat":
+			if strings.Contains(m.URL, "err") {
+				send(out, msg{Event: "complete", Oid: m.Oid, Error: "fakeadapter: simulated stat failure"})
+				continue
+			}
+			send(out, msg{Event: "meta", Oid: m.Oid, Size: int64(len(fakeContent)), ETag: "fake-etag", LastModified: "fake-last-modified"})
+		case "download":
+			if strings.Contains(m.URL, "err") {
+				send(out, msg{Event: "complete", Oid: m.Oid, Error: "fakeadapter: simulated download failure"})
+				continue
+			}
+			body := fakeContent[m.Offset:]
+			send(out, msg{Event: "progress", Oid: m.Oid, BytesSoFar: m.Offset})
+			f, err := os.CreateTemp("", "fakeadapter-*")
+			if err != nil {
+				send(out, msg{Event: "complete", Oid: m.Oid, Error: err.Error()})
+				continue
+			}
+			if _, err := f.WriteString(body); err != nil {
+				send(out, msg{Event: "complete", Oid: m.Oid, Error: err.Error()})
+				continue
+			}
+			f.Close()
+			send(out, msg{Event: "progress", Oid: m.Oid, BytesSoFar: int64(len(fakeContent))})
+			send(out, msg{Event: "complete", Oid: m.Oid, Path: f.Name()})
+		default:
+			send(out, msg{Event: "complete", Oid: m.Oid, Error: fmt.Sprintf("fakeadapter: unknown event %q", m.Event)})
+		}
+	}
+}
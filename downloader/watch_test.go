@@ -0,0 +1,77 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchAggregatorStartedPassesThrough(t *testing.T) {
+	w := newWatchAggregator()
+	out, ok := w.observe(Event{Kind: EvStarted, ObjName: "o1", Attempt: 2})
+	if !ok {
+		t.Fatal("expected an observed event")
+	}
+	if out.Kind != WatchStarted || out.ObjName != "o1" || out.Retry != 2 {
+		t.Errorf("got %+v, want Kind=Started ObjName=o1 Retry=2", out)
+	}
+}
+
+func TestWatchAggregatorFirstProgressHasNoDelta(t *testing.T) {
+	w := newWatchAggregator()
+	_, ok := w.observe(Event{Kind: EvProgress, ObjName: "o1", Bytes: 100, Total: 1000, At: time.Now()})
+	if ok {
+		t.Error("expected the first Progress event for a task to produce nothing (no prior sample to diff)")
+	}
+}
+
+func TestWatchAggregatorSecondProgressComputesDeltaAndSpeed(t *testing.T) {
+	w := newWatchAggregator()
+	t0 := time.Now()
+	w.observe(Event{Kind: EvProgress, ObjName: "o1", Bytes: 100, Total: 1000, At: t0})
+
+	out, ok := w.observe(Event{Kind: EvProgress, ObjName: "o1", Bytes: 300, Total: 1000, At: t0.Add(time.Second)})
+	if !ok {
+		t.Fatal("expected the second Progress event to produce a BytesTransferred update")
+	}
+	if out.Kind != WatchTransferred || out.Delta != 200 || out.Total != 1000 {
+		t.Errorf("got %+v, want Kind=BytesTransferred Delta=200 Total=1000", out)
+	}
+	if out.SpeedBps != 200 {
+		t.Errorf("SpeedBps = %v, want 200 (200 bytes over 1s)", out.SpeedBps)
+	}
+}
+
+func TestWatchAggregatorFinishedAndFailedClearTaskState(t *testing.T) {
+	w := newWatchAggregator()
+	w.observe(Event{Kind: EvProgress, ObjName: "o1", Bytes: 100, At: time.Now()})
+
+	out, ok := w.observe(Event{Kind: EvFinished, ObjName: "o1"})
+	if !ok || out.Kind != WatchTaskFinished {
+		t.Fatalf("got %+v, ok=%v, want TaskFinished", out, ok)
+	}
+	if _, tracked := w.tasks["o1"]; tracked {
+		t.Error("expected task state to be cleared after TaskFinished")
+	}
+
+	w.observe(Event{Kind: EvProgress, ObjName: "o2", Bytes: 50, At: time.Now()})
+	out2, ok2 := w.observe(Event{Kind: EvFailed, ObjName: "o2", Attempt: 3, Err: "boom"})
+	if !ok2 || out2.Kind != WatchTaskFailed || out2.Retry != 3 || out2.Err != "boom" {
+		t.Fatalf("got %+v, ok=%v, want TaskFailed Retry=3 Err=boom", out2, ok2)
+	}
+	if _, tracked := w.tasks["o2"]; tracked {
+		t.Error("expected task state to be cleared after TaskFailed")
+	}
+}
+
+func TestWatchAggregatorIgnoresUninterestingKinds(t *testing.T) {
+	w := newWatchAggregator()
+	for _, kind := range []EventKind{EvScheduled, EvRetry, EvDropped, EvAborted} {
+		if _, ok := w.observe(Event{Kind: kind, ObjName: "o1"}); ok {
+			t.Errorf("expected Kind=%s to produce no DlProgressEvent", kind)
+		}
+	}
+}
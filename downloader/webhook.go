@@ -0,0 +1,79 @@
+// Package downloader implements functionality to download resources into AIS cluster from external source.
+/*
+ * Copyright (c) 2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NotifyConfig is `DlBase.NotifyURL`/`DlBase.NotifySecret`: an optional
+// webhook a job body can ask to be POSTed to once the job finishes, in the
+// spirit of Minio's webhook-notification target. Secret is empty for an
+// unsigned webhook, or an HMAC-SHA256 key to sign the body with (same
+// "secret signs the raw payload, receiver recomputes and compares" pattern
+// Minio's authToken webhook and most other webhook providers use).
+type NotifyConfig struct {
+	URL    string `json:"notify_url"`
+	Secret string `json:"notify_secret,omitempty"`
+}
+
+// signatureHeader is where the HMAC-SHA256 hex digest of the JSON body is
+// sent, named after GitHub/Minio's own "sha256=<hex>" webhook convention so
+// existing receiver libraries can verify it unmodified.
+const signatureHeader = "X-Signature-256"
+
+// CompletionSummary is the JSON body POSTed to NotifyConfig.URL once a job
+// reaches a terminal state.
+type CompletionSummary struct {
+	JobID      string `json:"job_id"`
+	Bucket     string `json:"bucket"`
+	Total      int    `json:"total"`
+	Finished   int    `json:"finished"`
+	Failed     int    `json:"failed"`
+	Aborted    bool   `json:"aborted"`
+	FinishedAt string `json:"finished_at"`
+}
+
+// notifyCompletion POSTs summary as JSON to cfg.URL, signing it with
+// cfg.Secret if set. It's the last thing a job's completion path does, best
+// effort like infoStore.persist - a notify failure is logged by the caller,
+// not folded back into the job's own success/failure.
+func notifyCompletion(ctx context.Context, client *http.Client, cfg NotifyConfig, summary CompletionSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Secret != "" {
+		req.Header.Set(signatureHeader, "sha256="+signBody(cfg.Secret, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("downloader: notify %s: status %d", cfg.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
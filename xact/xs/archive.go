@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/NVIDIA/aistore/cmn/cos"
 	"github.com/NVIDIA/aistore/cmn/debug"
 	"github.com/NVIDIA/aistore/fs"
+	"github.com/NVIDIA/aistore/memsys"
 	"github.com/NVIDIA/aistore/transport"
 	"github.com/NVIDIA/aistore/xact"
 	"github.com/NVIDIA/aistore/xact/xreg"
@@ -49,9 +51,12 @@ type (
 	}
 	XactArch struct {
 		streamingX
-		workCh  chan *cmn.ArchiveMsg
-		config  *cmn.Config
-		bckTo   *meta.Bck
+		workCh chan *cmn.ArchiveMsg
+		config *cmn.Config
+		bckTo  struct {
+			m map[string]*meta.Bck // dst bucket uname => dst bucket; one-to-many destinations
+			sync.RWMutex
+		}
 		pending struct {
 			m map[string]*archwi
 			sync.RWMutex
@@ -79,6 +84,7 @@ func (p *archFactory) Start() error {
 	workCh := make(chan *cmn.ArchiveMsg, maxNumInParallel)
 	r := &XactArch{streamingX: streamingX{p: &p.streamingF}, workCh: workCh, config: cmn.GCO.Get()}
 	r.pending.m = make(map[string]*archwi, maxNumInParallel)
+	r.bckTo.m = make(map[string]*meta.Bck, 1) // +1 for the (typical) single-dst case; grows on demand
 	p.xctn = r
 	r.DemandBase.Init(p.UUID(), apc.ActArchive, p.Bck /*from*/, 0 /*use default*/)
 
@@ -145,7 +151,7 @@ func (r *XactArch) Begin(msg *cmn.ArchiveMsg) (err error) {
 		}
 
 		// construct format-specific writer
-		wi.writer = archive.NewWriter(msg.Mime, wi.fh, &wi.cksum, true /*serialize*/)
+		wi.writer = archive.NewWriter(msg.Mime, wi.fh, &wi.cksum, true /*serialize*/, msg.CompressionLevel)
 
 		// append
 		if lmfh != nil {
@@ -153,12 +159,15 @@ func (r *XactArch) Begin(msg *cmn.ArchiveMsg) (err error) {
 		}
 	}
 
-	// most of the time there'll be a single dst bucket for the lifetime
-	// TODO: extend `cluster.Xact` for one-source-to-many-destination buckets
-	if r.bckTo == nil {
-		if from := r.Bck().Bucket(); !from.Equal(&wi.msg.ToBck) {
-			r.bckTo = meta.CloneBck(&wi.msg.ToBck)
+	// one-source-to-many-destination: track every distinct dst bucket seen across
+	// the lifetime of this xaction (most of the time there's just the one)
+	if from := r.Bck().Bucket(); !from.Equal(&wi.msg.ToBck) {
+		uname := wi.msg.ToBck.MakeUname("")
+		r.bckTo.Lock()
+		if _, ok := r.bckTo.m[uname]; !ok {
+			r.bckTo.m[uname] = meta.CloneBck(&wi.msg.ToBck)
 		}
+		r.bckTo.Unlock()
 	}
 
 	r.pending.Lock()
@@ -289,7 +298,13 @@ func (r *XactArch) recv(hdr transport.ObjHdr, objReader io.Reader, err error) er
 		return nil
 	}
 	debug.Assert(hdr.Opcode == 0)
-	err = wi.writer.Write(wi.nameInArch(hdr.ObjName), &hdr.ObjAttrs, objReader)
+	name, oah, reader, sgl, err := encodeArchMember(wi.msg.MemberCodec, wi.nameInArch(hdr.ObjName), &hdr.ObjAttrs, objReader)
+	if err == nil {
+		err = wi.writer.Write(name, oah, reader)
+	}
+	if sgl != nil {
+		sgl.Free()
+	}
 	if err != nil {
 		r.raiseErr(err, wi.msg.ContinueOnError)
 	}
@@ -336,34 +351,63 @@ func (r *XactArch) fini(wi *archwi) (errCode int, err error) {
 
 func (r *XactArch) Name() (s string) {
 	s = r.streamingX.Name()
-	if src, dst := r.FromTo(); src != nil {
-		s += " => " + dst.String()
+	if dsts := r.dstBcks(); len(dsts) > 0 {
+		s += " => " + strings.Join(dsts, ",")
 	}
 	return
 }
 
 func (r *XactArch) String() (s string) {
 	s = r.streamingX.String() + " => "
-	if r.wiCnt.Load() > 0 && r.bckTo != nil {
-		s += r.bckTo.String()
+	if r.wiCnt.Load() > 0 {
+		s += strings.Join(r.dstBcks(), ",")
 	}
 	return
 }
 
+// FromTo returns source and, for backwards compatibility, one destination bucket -
+// with several destinations, whichever map iteration yields first, so not a fixed one;
+// for the full set of (possibly multiple) destinations see `Snap.DstBcks`.
 func (r *XactArch) FromTo() (src, dst *meta.Bck) {
-	if r.bckTo != nil {
-		src, dst = r.Bck(), r.bckTo
+	r.bckTo.RLock()
+	defer r.bckTo.RUnlock()
+	if len(r.bckTo.m) == 0 {
+		return
+	}
+	src = r.Bck()
+	for _, b := range r.bckTo.m {
+		dst = b
+		break
 	}
 	return
 }
 
+func (r *XactArch) dstBcks() []string {
+	r.bckTo.RLock()
+	defer r.bckTo.RUnlock()
+	dsts := make([]string, 0, len(r.bckTo.m))
+	for _, b := range r.bckTo.m {
+		dsts = append(dsts, b.String())
+	}
+	return dsts
+}
+
 func (r *XactArch) Snap() (snap *cluster.Snap) {
 	snap = &cluster.Snap{}
 	r.ToSnap(snap)
 
 	snap.IdleX = r.IsIdle()
-	if f, t := r.FromTo(); f != nil {
-		snap.SrcBck, snap.DstBck = f.Clone(), t.Clone()
+	r.bckTo.RLock()
+	defer r.bckTo.RUnlock()
+	if len(r.bckTo.m) > 0 {
+		snap.SrcBck = r.Bck().Clone()
+		snap.DstBcks = make([]cmn.Bck, 0, len(r.bckTo.m))
+		for _, b := range r.bckTo.m {
+			snap.DstBcks = append(snap.DstBcks, *b.Clone())
+			if snap.DstBck == nil {
+				snap.DstBck = b.Clone() // one dst (in map order), kept for callers not yet aware of `DstBcks`
+			}
+		}
 	}
 	return
 }
@@ -379,6 +423,10 @@ func (wi *archwi) beginAppend() (lmfh *os.File, err error) {
 			return
 		}
 	}
+	if msg.Mime == archive.ExtMsgpack {
+		err = wi.openMsgpackForAppend()
+		return
+	}
 	switch msg.Mime {
 	case archive.ExtTar, archive.ExtTgz, archive.ExtTarTgz, archive.ExtZip:
 		// to copy `lmfh` --> `wi.fh` with subsequent APPEND-ing
@@ -390,12 +438,37 @@ func (wi *archwi) beginAppend() (lmfh *os.File, err error) {
 			cos.Close(lmfh)
 			lmfh = nil
 		}
-	default: // TODO -- FIXME: add .msgpack
+	case archive.ExtTarZst, archive.ExtTarXz:
+		// neither zstd nor xz frames are trivially seekable/truncatable; rather than
+		// decode-and-reencode the entire archive on every APPEND, we reject it cleanly
+		// (caller may PUT a new version instead)
+		err = fmt.Errorf("cannot APPEND to %s - %q doesn't support appending (PUT a new version instead)",
+			msg.Cname(), msg.Mime)
+	default:
 		err = fmt.Errorf("cannot APPEND to %s - %q not implemented yet", msg.Cname(), msg.Mime)
 	}
 	return
 }
 
+// openMsgpackForAppend mirrors openTarForAppend: rename lom -> workFQN, seek past
+// the existing records (discarding the stale trailing index), and continue writing.
+func (wi *archwi) openMsgpackForAppend() (err error) {
+	if err = os.Rename(wi.lom.FQN, wi.fqn); err != nil {
+		return
+	}
+	wi.fh, wi.appendPos, err = archive.OpenMsgpackForAppend(wi.fqn)
+	if err == nil {
+		return
+	}
+	if errV := wi.lom.RenameFrom(wi.fqn); errV != nil {
+		glog.Errorf("%s: nested error: failed to append %s (%v) and rename back from %s (%v)",
+			wi.tsi, wi.lom, err, wi.fqn, errV)
+	} else {
+		wi.fqn = ""
+	}
+	return
+}
+
 func (wi *archwi) openTarForAppend() (err error) {
 	if err = os.Rename(wi.lom.FQN, wi.fqn); err != nil {
 		return
@@ -420,6 +493,39 @@ roll:
 	return
 }
 
+// encodeMember applies `wi.msg.MemberCodec` (none|gzip|zstd|lz4), if any, to
+// one member's content ahead of handing it to the container-format writer.
+//
+// This used to also drive a content-defined-chunking zstd dictionary-training
+// pre-pass (cmn/archive/cdc.go), sharing one trained dictionary across every
+// zstd member the way shard-similarity-aware tools do. That path is gone:
+// nothing in this tree ever read `archive.DictMemberName` back or decoded a
+// member written against it (no `zstd.WithDecoderDicts` anywhere), so any
+// archive that finished training was permanently unreadable through this
+// codebase. Re-add dictionary training only alongside a matching decode path.
+func (r *XactArch) encodeMember(wi *archwi, lom *cluster.LOM, fh cos.ReadOpenCloser) (
+	name string, oah cos.OAH, reader io.Reader, sgl *memsys.SGL, err error) {
+	return encodeArchMember(wi.msg.MemberCodec, wi.nameInArch(lom.ObjName), lom, fh)
+}
+
+// encodeArchMember applies `codec` (none|gzip|zstd|lz4) to one member's content,
+// if any, ahead of handing it to the container-format writer - shared by the
+// local-object path (encodeMember, above) and the cross-target recv path below,
+// so that a member is encoded the same way regardless of which target owns it.
+func encodeArchMember(codec archive.PerMemberCodec, name string, oah cos.OAH, reader io.Reader) (
+	string, cos.OAH, io.Reader, *memsys.SGL, error) {
+	if codec == archive.CodecNone {
+		return name, oah, reader, nil, nil
+	}
+	sgl, err := archive.EncodeMember(codec, reader)
+	if err != nil {
+		return "", nil, nil, nil, err
+	}
+	name = archive.NameWithCodec(name, codec)
+	oah = archive.WithSize(oah, sgl.Len())
+	return name, oah, sgl, sgl, nil
+}
+
 func (wi *archwi) do(lom *cluster.LOM, lrit *lriterator) {
 	var coldGet bool
 	if err := lom.Load(false /*cache it*/, false /*locked*/); err != nil {
@@ -456,9 +562,16 @@ func (wi *archwi) do(lom *cluster.LOM, lrit *lriterator) {
 		return
 	}
 	debug.Assert(wi.fh != nil) // see Begin
-	err = wi.writer.Write(wi.nameInArch(lom.ObjName), lom, fh)
+
+	name, oah, reader, sgl, err := wi.r.encodeMember(wi, lom, fh)
+	if err == nil {
+		err = wi.writer.Write(name, oah, reader)
+	}
 	cluster.FreeLOM(lom)
 	cos.Close(fh)
+	if sgl != nil {
+		sgl.Free()
+	}
 	if err != nil {
 		wi.r.raiseErr(err, wi.msg.ContinueOnError)
 	}
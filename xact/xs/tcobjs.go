@@ -0,0 +1,372 @@
+// Package xs is a collection of eXtended actions (xactions), including multi-object
+// operations, list-objects, (cluster) rebalance and (target) resilver, ETL, and more.
+/*
+ * Copyright (c) 2021-2023, NVIDIA CORPORATION. All rights reserved.
+ */
+package xs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/aistore/3rdparty/glog"
+	"github.com/NVIDIA/aistore/api/apc"
+	"github.com/NVIDIA/aistore/cluster"
+	"github.com/NVIDIA/aistore/cluster/meta"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/NVIDIA/aistore/etl"
+	"github.com/NVIDIA/aistore/fs"
+	"github.com/NVIDIA/aistore/memsys"
+	"github.com/NVIDIA/aistore/xact"
+	"github.com/NVIDIA/aistore/xact/xreg"
+)
+
+type (
+	tcoFactory struct {
+		streamingF
+		kind string // apc.ActCopyObjects (plain copy) | apc.ActETLObjects (0+ ETL stages)
+	}
+
+	// tcowi is the (single, long-lived) work item for one CopyMultiObj/
+	// ETLMultiObj request: `do` runs once per source object the iterator
+	// hands it, piping that object's content through r.stages - stage N's
+	// output is stage N+1's input, so a multi-stage pipeline never
+	// materializes the intermediate result on disk or on the wire any more
+	// than a single-stage transform would - and PUTs whatever comes out the
+	// far end to ToBck. Zero stages (plain CopyMultiObj) skips straight to
+	// the PUT.
+	tcowi struct {
+		r *XactTCObjs
+	}
+
+	XactTCObjs struct {
+		streamingX
+		selmsg   *cmn.SelectObjsMsg
+		contErr  bool
+		stages   []apc.ETLStage  // nil => plain copy; len==1 => single ETL; len>1 => chained pipeline
+		verify   bool            // VerifyChecksum: recompute dst checksum while copying, fail-closed on mismatch
+		manifest *manifestWriter // non-nil when ManifestBck/ManifestObj were set
+		toBck    struct {
+			b meta.Bck
+			sync.RWMutex
+		}
+		config *cmn.Config
+	}
+
+	// manifestWriter accumulates one ndjson manifestEntry per object this
+	// xaction lands, and PUTs the accumulated lines to bck/obj once, at
+	// finalize - so a caller can audit exactly what copied/transformed
+	// without a second full-bucket list-and-diff.
+	manifestWriter struct {
+		bck cmn.Bck
+		obj string
+		mu  sync.Mutex
+		buf bytes.Buffer
+	}
+
+	// manifestEntry mirrors the ndjson record layout ais/tests/
+	// etl_copy_multiobj_test.go parses back out of ManifestObj.
+	manifestEntry struct {
+		Src        string `json:"src"`
+		Dst        string `json:"dst"`
+		Size       int64  `json:"size"`
+		CksumType  string `json:"cksum_type"`
+		CksumValue string `json:"cksum_value"`
+		XactID     string `json:"xact_id"`
+		Ts         int64  `json:"ts"`
+	}
+)
+
+func (m *manifestWriter) append(e manifestEntry) error {
+	b, err := json.Marshal(&e)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.buf.Write(b)
+	m.buf.WriteByte('\n')
+	m.mu.Unlock()
+	return nil
+}
+
+// flush PUTs the accumulated ndjson lines to m.bck/m.obj. Called once, from
+// XactTCObjs.Run, after every selected object has either landed or been
+// skipped - never mid-xaction, so the manifest is all-or-nothing.
+func (m *manifestWriter) flush(t cluster.Target) error {
+	m.mu.Lock()
+	raw := m.buf.Bytes()
+	m.mu.Unlock()
+	if len(raw) == 0 {
+		return nil
+	}
+	dst := cluster.AllocLOM(m.obj)
+	defer cluster.FreeLOM(dst)
+	if err := dst.InitBck(&m.bck); err != nil {
+		return err
+	}
+	fqn := fs.CSM.Gen(dst, fs.WorkfileType, fs.WorkfileCreateTCO)
+	_, err := writeDst(t, dst, fqn, bytes.NewReader(raw), false /*verify*/, nil)
+	return err
+}
+
+// interface guard
+var (
+	_ cluster.Xact   = (*XactTCObjs)(nil)
+	_ xreg.Renewable = (*tcoFactory)(nil)
+	_ lrwi           = (*tcowi)(nil)
+)
+
+/////////////////
+// tcoFactory //
+/////////////////
+
+func (p *tcoFactory) New(args xreg.Args, bck *meta.Bck) xreg.Renewable {
+	return &tcoFactory{streamingF: streamingF{RenewBase: xreg.RenewBase{Args: args, Bck: bck}, kind: p.kind}}
+}
+
+// Start accepts either of the two request shapes CopyMultiObj/ETLMultiObj
+// hand it: a single-stage `cmn.TCObjsMsg` (TCBMsg.ID empty => plain copy,
+// set => one ETL stage) or a chained `cmn.TCObjsPipelineMsg` (Stages); both
+// embed cmn.SelectObjsMsg and carry a ToBck, which is all XactTCObjs itself
+// needs to know.
+func (p *tcoFactory) Start() error {
+	r := &XactTCObjs{streamingX: streamingX{p: &p.streamingF}, config: cmn.GCO.Get()}
+
+	switch msg := p.Args.Custom.(type) {
+	case *cmn.TCObjsMsg:
+		r.selmsg, r.contErr = &msg.SelectObjsMsg, msg.ContinueOnError
+		r.toBck.b = *meta.CloneBck(&msg.ToBck)
+		if msg.TCBMsg.ID != "" {
+			r.stages = []apc.ETLStage{{TCBMsg: msg.TCBMsg}}
+		}
+		r.verify = msg.VerifyChecksum
+		if msg.ManifestObj != "" {
+			r.manifest = &manifestWriter{bck: msg.ManifestBck, obj: msg.ManifestObj}
+		}
+	case *cmn.TCObjsPipelineMsg:
+		r.selmsg, r.contErr = &msg.SelectObjsMsg, msg.ContinueOnError
+		r.toBck.b = *meta.CloneBck(&msg.ToBck)
+		r.stages = msg.Stages
+	default:
+		return fmt.Errorf("%s: expecting *cmn.TCObjsMsg or *cmn.TCObjsPipelineMsg, got %T", p, p.Args.Custom)
+	}
+
+	p.xctn = r
+	r.DemandBase.Init(p.UUID(), p.kind, p.Bck, 0 /*use default*/)
+
+	bmd := p.Args.T.Bowner().Get()
+	trname := fmt.Sprintf("tco-%s%s-%s-%d", p.Bck.Provider, p.Bck.Ns, p.Bck.Name, bmd.Version)
+	if err := p.newDM(trname, r.recv, 0 /*pdu*/); err != nil {
+		return err
+	}
+	r.p.dm.SetXact(r)
+	r.p.dm.Open()
+
+	xact.GoRunW(r)
+	return nil
+}
+
+//////////////////
+// XactTCObjs //
+//////////////////
+
+func (r *XactTCObjs) Run(wg *sync.WaitGroup) {
+	glog.Infoln(r.Name())
+	wg.Done()
+
+	wi := &tcowi{r: r}
+	lrit := &lriterator{}
+	lrit.init(r, r.p.T, &r.selmsg.ListRange, false /*freeLOM: wi frees the src, not the iterator*/)
+
+	var err error
+	smap := r.p.T.Sowner().Get()
+	if r.selmsg.IsList() {
+		err = lrit.iterateList(wi, smap)
+	} else {
+		err = lrit.iterateRange(wi, smap)
+	}
+	if err == nil {
+		err = r.AbortErr()
+	}
+	if err == nil && r.manifest != nil {
+		err = r.manifest.flush(r.p.T)
+	}
+	r.streamingX.fin(err, true /*unreg Rx*/)
+}
+
+// do pipes one source object through r.stages (if any), PUTs the result to
+// ToBck, and - if VerifyChecksum/ManifestObj were set - rehashes the dst as
+// it writes and appends a manifestEntry; it's the lrwi callback the
+// iterator in Run invokes once per object selected by msg.ListRange.
+func (wi *tcowi) do(lom *cluster.LOM, _ *lriterator) {
+	r := wi.r
+	defer cluster.FreeLOM(lom)
+
+	dst := cluster.AllocLOM(lom.ObjName)
+	r.toBck.RLock()
+	err := dst.InitBck(r.toBck.b.Bucket())
+	r.toBck.RUnlock()
+	if err != nil {
+		cluster.FreeLOM(dst)
+		r.raiseErr(err, r.contErr)
+		return
+	}
+	defer cluster.FreeLOM(dst)
+	fqn := fs.CSM.Gen(dst, fs.WorkfileType, fs.WorkfileCreateTCO)
+
+	src, err := cos.NewFileHandle(lom.FQN)
+	if err != nil {
+		r.raiseErr(err, r.contErr)
+		return
+	}
+	defer cos.Close(src)
+
+	out, cleanup, err := r.pipeline(lom, src)
+	if err != nil {
+		r.raiseErr(err, r.contErr)
+		return
+	}
+	defer cleanup()
+
+	// srcCksum only makes sense as a pre-FinalizeObj equality check for a plain
+	// copy (dst bytes == src bytes); once r.stages transforms the content the
+	// dst digest is expected to differ from the source's, so there's nothing
+	// meaningful to compare it against - just record the computed dst checksum.
+	var srcCksum *cos.Cksum
+	if len(r.stages) == 0 {
+		srcCksum = lom.Checksum()
+	}
+	ckh, err := writeDst(r.p.T, dst, fqn, out, r.verify, srcCksum)
+	if err != nil {
+		r.raiseErr(err, r.contErr)
+		return
+	}
+	if r.verify {
+		dst.SetCksum(&ckh.Cksum)
+	}
+	r.ObjsAdd(1, dst.SizeBytes())
+
+	if r.manifest != nil {
+		e := manifestEntry{
+			Src:    r.Bck().Bucket().DisplayName() + "/" + lom.ObjName,
+			Dst:    r.toBck.b.Bucket().DisplayName() + "/" + dst.ObjName,
+			Size:   dst.SizeBytes(),
+			XactID: r.ID(),
+			Ts:     time.Now().Unix(),
+		}
+		if r.verify {
+			e.CksumType, e.CksumValue = ckh.Cksum.Type(), ckh.Cksum.Value()
+		}
+		if errM := r.manifest.append(e); errM != nil {
+			r.raiseErr(errM, r.contErr)
+		}
+	}
+}
+
+// pipeline chains r.stages, each via etl.GetCommunicator(stage.TCBMsg.ID),
+// and returns the final reader along with a cleanup func that closes every
+// intermediate pipe/reader it opened along the way.
+func (r *XactTCObjs) pipeline(lom *cluster.LOM, src io.Reader) (out io.Reader, cleanup func(), err error) {
+	if len(r.stages) == 0 {
+		return src, func() {}, nil
+	}
+	closers := make([]io.Closer, 0, len(r.stages))
+	cleanup = func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			cos.Close(closers[i])
+		}
+	}
+	cur := src
+	for i, stage := range r.stages {
+		comm, errN := etl.GetCommunicator(stage.TCBMsg.ID)
+		if errN != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("%s: stage %d (%s): %v", r, i, stage.TCBMsg.ID, errN)
+		}
+		rc, errN := comm.OfflineTransform(lom, cur, stage.TCBMsg.RequestTimeout.D())
+		if errN != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("%s: stage %d (%s): %v", r, i, stage.TCBMsg.ID, errN)
+		}
+		closers = append(closers, rc)
+		cur = rc
+	}
+	return cur, cleanup, nil
+}
+
+// writeDst copies src to dst's workfile and, only once the write passes
+// verification, finalizes it in place. When verify is true, it hashes the
+// bytes as they're written (dst.CksumType()) and - if srcCksum is set and
+// shares that same checksum type - compares the two digests *before*
+// calling FinalizeObj: a mismatch removes the still-unfinalized workfile
+// and returns an error without ever promoting the corrupt bytes into the
+// bucket, so a rejected copy never becomes live/listable in ToBck. When
+// verify is false, ckh.Cksum is the zero value and the copy is a plain
+// passthrough.
+func writeDst(t cluster.Target, dst *cluster.LOM, fqn string, src io.Reader, verify bool, srcCksum *cos.Cksum) (ckh cos.CksumHashSize, err error) {
+	fh, err := dst.CreateFile(fqn)
+	if err != nil {
+		return ckh, err
+	}
+	buf, slab := memsys.PageMM().Alloc()
+	var w io.Writer = fh
+	if verify {
+		ckh.Init(dst.CksumType())
+		w = io.MultiWriter(fh, &ckh)
+	}
+	_, err = io.CopyBuffer(w, src, buf)
+	slab.Free(buf)
+	cos.Close(fh)
+	if err != nil {
+		cos.RemoveFile(fqn)
+		return ckh, err
+	}
+	if verify {
+		ckh.Finalize()
+		if srcCksum != nil && srcCksum.Type() == ckh.Cksum.Type() &&
+			srcCksum.Value() != "" && srcCksum.Value() != ckh.Cksum.Value() {
+			cos.RemoveFile(fqn)
+			return ckh, fmt.Errorf("checksum mismatch copying to %s (src %s, dst %s)",
+				dst, srcCksum.Value(), ckh.Cksum.Value())
+		}
+	}
+	errCode, err := t.FinalizeObj(dst, fqn, nil)
+	if err != nil {
+		return ckh, fmt.Errorf("finalize %s failed (code %d): %v", dst, errCode, err)
+	}
+	return ckh, nil
+}
+
+func (r *XactTCObjs) recv(_ interface{ Bck() cmn.Bck }, _ io.Reader, err error) error {
+	return err // single-target-local in this build: nothing crosses the wire (yet)
+}
+
+func (r *XactTCObjs) Name() (s string) {
+	s = r.streamingX.Name()
+	r.toBck.RLock()
+	s += " => " + r.toBck.b.String()
+	r.toBck.RUnlock()
+	return s
+}
+
+func (r *XactTCObjs) FromTo() (src, dst *meta.Bck) {
+	r.toBck.RLock()
+	defer r.toBck.RUnlock()
+	return r.Bck(), &r.toBck.b
+}
+
+func (r *XactTCObjs) Snap() (snap *cluster.Snap) {
+	snap = &cluster.Snap{}
+	r.ToSnap(snap)
+	snap.IdleX = r.IsIdle()
+	snap.SrcBck = r.Bck().Clone()
+	r.toBck.RLock()
+	snap.DstBck = r.toBck.b.Clone()
+	r.toBck.RUnlock()
+	return
+}